@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solve"
+)
+
+// batchShift is added to solve times, in seconds, before taking their
+// geometric mean, so very fast runs don't dominate the comparison.
+const batchShift = 1.0
+
+// batchRun is one instance solved with one parameter combination.
+type batchRun struct {
+	config    int
+	flags     *flags
+	problem   *tsppd.Problem
+	incumbent ddo.State
+	stats     ddo.Statistics
+}
+
+// solved returns true if search ended with a proof of optimality or
+// infeasibility.
+func (r *batchRun) solved() bool {
+	return r.stats.Termination == ddo.Optimal || r.stats.Termination == ddo.Infeasible
+}
+
+// isBatch returns true if flags name a directory or glob of inputs, or a
+// sweep over parameter combinations.
+func isBatch(f *flags) bool {
+	if f.sweep() != "" {
+		return true
+	}
+	if f.input() == "-" {
+		return false
+	}
+	if strings.ContainsAny(f.input(), "*?[") {
+		return true
+	}
+	info, err := os.Stat(f.input())
+	return err == nil && info.IsDir()
+}
+
+// runBatch solves every input with every parameter combination in the
+// sweep, writing a CSV row for each run and then a summary table.
+func runBatch(f *flags) {
+	if f.parallel() < 1 {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("parallel must be >= 1"))
+		os.Exit(1)
+	}
+	if f.solution() != "" {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("solution file not supported in batch mode"))
+		os.Exit(1)
+	}
+	if f.output() != "" {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("output format not supported in batch mode"))
+		os.Exit(1)
+	}
+	if f.verbosity() != 0 {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("verbosity not supported in batch mode"))
+		os.Exit(1)
+	}
+
+	configs, err := batchConfigs(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	inputs, err := batchInputs(f.input())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Read every instance up front, so bad input is reported before any
+	// time is spent on search. Runs with different configs share it.
+	var runs []*batchRun
+	for _, input := range inputs {
+		problem := readProblem(input)
+		for i, config := range configs {
+			runs = append(runs, &batchRun{config: i, flags: config, problem: problem})
+		}
+	}
+
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write([]string{
+		"instance",
+		"size",
+		"form",
+		"infer",
+		"relax",
+		"ordering",
+		"width",
+		"batch",
+		"workers",
+		"maxmillis",
+		"maxnodes",
+		"clock",
+		"cpu",
+		"primal",
+		"dual",
+		"optimal",
+		"termination",
+		"nodes",
+		"fails",
+		"path",
+	})
+	writer.Flush()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopOnSignal(cancel)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan *batchRun)
+	for i := 0; i < f.parallel(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range jobs {
+				result, err := solve.Solve(ctx, run.problem, run.flags.options(nil))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", run.problem.Name, err)
+					continue
+				}
+				run.incumbent, run.stats = result.Incumbent, result.Statistics
+
+				mutex.Lock()
+				writer.Write(run.record())
+				writer.Flush()
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, run := range runs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- run
+	}
+	close(jobs)
+	wg.Wait()
+
+	writeBatchSummary(configs, runs)
+}
+
+// batchConfigs returns a copy of flags for each parameter combination in
+// the sweep, or just flags if there is no sweep.
+func batchConfigs(f *flags) ([]*flags, error) {
+	if f.sweep() == "" {
+		return []*flags{f}, f.check()
+	}
+
+	var configs []*flags
+	for _, settings := range strings.Split(f.sweep(), ";") {
+		settings = strings.TrimSpace(settings)
+		if settings == "" {
+			continue
+		}
+		config, err := f.override(settings)
+		if err == nil {
+			err = config.check()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sweep %q: %v", settings, err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// batchInputs expands a directory to the JSON files in it, or a glob to
+// the files it matches.
+func batchInputs(input string) ([]string, error) {
+	pattern := input
+	if info, err := os.Stat(input); err == nil && info.IsDir() {
+		pattern = filepath.Join(input, "*.json")
+	} else if !strings.ContainsAny(input, "*?[") {
+		return []string{input}, nil
+	}
+
+	inputs, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input files match %s", pattern)
+	}
+	sort.Strings(inputs)
+	return inputs, nil
+}
+
+// record returns a CSV row for a finished run.
+func (r *batchRun) record() []string {
+	f := r.flags
+	var primal, path string
+	if r.incumbent != nil {
+		primal = strconv.FormatInt(r.incumbent.Cost(), 10)
+		path = strings.Join(r.incumbent.(tsppd.State).Solution().Path, " ")
+	}
+
+	return []string{
+		r.problem.Name,
+		strconv.Itoa(len(r.problem.Nodes)),
+		f.form(),
+		f.infer(),
+		f.relax(),
+		f.ordering(),
+		strconv.Itoa(int(f.width())),
+		strconv.Itoa(f.batch()),
+		strconv.Itoa(f.workers()),
+		strconv.FormatUint(f.maxmillis(), 10),
+		strconv.FormatUint(f.maxnodes(), 10),
+		fmt.Sprintf("%.10f", r.stats.ClockSeconds),
+		fmt.Sprintf("%.10f", r.stats.CPUSeconds),
+		primal,
+		strconv.FormatInt(r.stats.DualBound, 10),
+		strconv.FormatBool(r.stats.Optimal),
+		r.stats.Termination.String(),
+		strconv.FormatUint(r.stats.Nodes, 10),
+		strconv.FormatUint(r.stats.Fails, 10),
+		path,
+	}
+}
+
+// writeBatchSummary writes a table to stderr with the number of runs
+// solved, the mean solve time, and the shifted geometric mean solve time
+// for each parameter combination. Runs that were never started due to
+// interruption, or that failed, are left out.
+func writeBatchSummary(configs []*flags, runs []*batchRun) {
+	fmt.Fprint(os.Stderr, "form           infer        relax  ordering        width     ")
+	fmt.Fprintln(os.Stderr, "runs   solved  mean       sgm")
+	for i := 0; i < 100; i++ {
+		fmt.Fprint(os.Stderr, "=")
+	}
+	fmt.Fprintln(os.Stderr)
+
+	for i, f := range configs {
+		var count, solved int
+		var sum, logSum float64
+		for _, run := range runs {
+			if run.config != i || run.stats.Termination == ddo.Running {
+				continue
+			}
+			count++
+			if run.solved() {
+				solved++
+			}
+			sum += run.stats.ClockSeconds
+			logSum += math.Log(run.stats.ClockSeconds + batchShift)
+		}
+
+		var mean, sgm float64
+		if count > 0 {
+			mean = sum / float64(count)
+			sgm = math.Exp(logSum/float64(count)) - batchShift
+		}
+
+		fmt.Fprintf(
+			os.Stderr,
+			"%-15s%-13s%-7s%-16s%-10d%-7d%-8d%-11.3f%-.3f\n",
+			f.form(),
+			f.infer(),
+			f.relax(),
+			f.ordering(),
+			f.width(),
+			count,
+			solved,
+			mean,
+			sgm,
+		)
+	}
+}
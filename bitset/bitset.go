@@ -0,0 +1,152 @@
+package bitset
+
+import "math/bits"
+
+const wordSize = 64
+
+// Set is a fixed-capacity set of non-negative integers packed into words.
+type Set struct {
+	words []uint64
+}
+
+// New creates an empty Set that can hold the integers 0 to size-1.
+func New(size int) *Set {
+	return &Set{words: make([]uint64, (size+wordSize-1)/wordSize)}
+}
+
+// Add inserts i into a Set.
+func (s *Set) Add(i int) {
+	s.words[i/wordSize] |= 1 << uint(i%wordSize)
+}
+
+// Remove takes i out of a Set.
+func (s *Set) Remove(i int) {
+	s.words[i/wordSize] &^= 1 << uint(i%wordSize)
+}
+
+// Contains returns true if i is in a Set.
+func (s *Set) Contains(i int) bool {
+	return s.words[i/wordSize]&(1<<uint(i%wordSize)) != 0
+}
+
+// Len returns the number of elements in a Set.
+func (s *Set) Len() int {
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// IsEmpty returns true if a Set has no elements.
+func (s *Set) IsEmpty() bool {
+	for _, w := range s.words {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Min returns the smallest element in a Set, or -1 if it is empty.
+func (s *Set) Min() int {
+	return s.Next(0)
+}
+
+// Next returns the smallest element >= i, or -1 if there is none.
+func (s *Set) Next(i int) int {
+	wi := i / wordSize
+	if wi >= len(s.words) {
+		return -1
+	}
+
+	w := s.words[wi] >> uint(i%wordSize)
+	if w != 0 {
+		return i + bits.TrailingZeros64(w)
+	}
+
+	for wi++; wi < len(s.words); wi++ {
+		if s.words[wi] != 0 {
+			return wi*wordSize + bits.TrailingZeros64(s.words[wi])
+		}
+	}
+	return -1
+}
+
+// Elements returns the members of a Set in increasing order.
+func (s *Set) Elements() []int {
+	elements := make([]int, 0, s.Len())
+	for i := s.Min(); i >= 0; i = s.Next(i + 1) {
+		elements = append(elements, i)
+	}
+	return elements
+}
+
+// Copy returns a new Set with the same elements.
+func (s *Set) Copy() *Set {
+	words := make([]uint64, len(s.words))
+	copy(words, s.words)
+	return &Set{words: words}
+}
+
+// Equal returns true if two Sets have the same elements.
+func (s *Set) Equal(other *Set) bool {
+	for i, w := range s.words {
+		if w != other.words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects returns true if two Sets have any elements in common.
+func (s *Set) Intersects(other *Set) bool {
+	for i, w := range s.words {
+		if w&other.words[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IntersectionLen returns the number of elements two Sets have in common.
+func (s *Set) IntersectionLen(other *Set) int {
+	n := 0
+	for i, w := range s.words {
+		n += bits.OnesCount64(w & other.words[i])
+	}
+	return n
+}
+
+// Union returns a new Set containing the elements of both Sets.
+func (s *Set) Union(other *Set) *Set {
+	u := &Set{words: make([]uint64, len(s.words))}
+	for i, w := range s.words {
+		u.words[i] = w | other.words[i]
+	}
+	return u
+}
+
+// UnionMinus returns a new Set containing the elements of both Sets
+// that are not in out.
+func (s *Set) UnionMinus(other, out *Set) *Set {
+	u := &Set{words: make([]uint64, len(s.words))}
+	for i, w := range s.words {
+		u.words[i] = (w | other.words[i]) &^ out.words[i]
+	}
+	return u
+}
+
+// AddAll inserts every element of other into a Set.
+func (s *Set) AddAll(other *Set) {
+	for i, w := range other.words {
+		s.words[i] |= w
+	}
+}
+
+// RemoveAll takes every element of other out of a Set.
+func (s *Set) RemoveAll(other *Set) {
+	for i, w := range other.words {
+		s.words[i] &^= w
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// presets map names to settings for common kinds of runs. Settings from a
+// config file or the command line take precedence over them.
+var presets = map[string]map[string]string{
+	// fast-realtime finds good solutions quickly under a short time limit.
+	"fast-realtime": {
+		"form":      "sequential",
+		"infer":     "ap",
+		"width":     "5",
+		"batch":     "10",
+		"construct": "regret",
+		"local":     "true",
+		"maxmillis": "1000",
+	},
+	// prove-optimal spends as long as needed proving optimality.
+	"prove-optimal": {
+		"form":       "sequential",
+		"infer":      "ap+arb",
+		"relax":      "dd",
+		"width":      "10",
+		"batch":      "10",
+		"construct":  "regret",
+		"local":      "true",
+		"preprocess": "true",
+	},
+}
+
+// applyConfig sets flags from a preset and a config file, without
+// overriding any flag given on the command line. The config file may name
+// its own preset, which the -preset flag overrides.
+func applyConfig(filename, preset string) error {
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	settings := map[string]string{}
+	if filename != "" {
+		var err error
+		if settings, err = readConfig(filename); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+
+	if !explicit["preset"] && settings["preset"] != "" {
+		preset = settings["preset"]
+	}
+	delete(settings, "preset")
+
+	if preset != "" {
+		values, ok := presets[preset]
+		if !ok {
+			return fmt.Errorf("unknown preset %q", preset)
+		}
+		for key, value := range values {
+			if _, ok := settings[key]; !ok {
+				settings[key] = value
+			}
+		}
+	}
+
+	for key, value := range settings {
+		if key == "config" || flag.Lookup(key) == nil {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := flag.Set(key, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, key, err)
+		}
+	}
+	return nil
+}
+
+// readConfig reads settings from a JSON object, or from YAML with one
+// "key: value" setting per line. Keys are flag names.
+func readConfig(filename string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(filename, ".json") || bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return readJSONConfig(b)
+	}
+	return readYAMLConfig(b)
+}
+
+func readJSONConfig(b []byte) (map[string]string, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{}
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			settings[key] = v
+		case bool:
+			settings[key] = strconv.FormatBool(v)
+		case float64:
+			settings[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("setting %q must be a string, number, or boolean", key)
+		}
+	}
+	return settings, nil
+}
+
+// readYAMLConfig reads the subset of YAML that config files need: a flat
+// mapping of keys to scalar values, with comments.
+func readYAMLConfig(b []byte) (map[string]string, error) {
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if trimmed := strings.TrimSpace(text); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if text == "---" {
+			continue
+		}
+
+		kv := strings.SplitN(text, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != kv[0] {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+		value, ok := yamlValue(kv[1])
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+		settings[kv[0]] = value
+	}
+	return settings, scanner.Err()
+}
+
+// yamlValue returns a scalar value without any comment after it. A # only
+// starts a comment outside of quotes. It returns false if there is no
+// value, or a quoted value isn't closed.
+func yamlValue(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || text[0] == '#' {
+		return "", false
+	}
+
+	var value, rest string
+	switch text[0] {
+	case '"':
+		end := 1
+		for ; end < len(text) && text[end] != '"'; end++ {
+			if text[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(text) {
+			return "", false
+		}
+		unquoted, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return "", false
+		}
+		value, rest = unquoted, text[end+1:]
+
+	case '\'':
+		// A single quote is escaped by doubling it.
+		end := 1
+		for ; end < len(text); end++ {
+			if text[end] == '\'' {
+				if end+1 < len(text) && text[end+1] == '\'' {
+					end++
+					continue
+				}
+				break
+			}
+		}
+		if end >= len(text) {
+			return "", false
+		}
+		value, rest = strings.ReplaceAll(text[1:end], "''", "'"), text[end+1:]
+
+	default:
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		return text, true
+	}
+
+	rest = strings.TrimSpace(rest)
+	return value, rest == "" || rest[0] == '#'
+}
+
+// echoConfig writes the effective value of every flag to stderr, as flags
+// that reproduce the run.
+func echoConfig() {
+	var settings []string
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "preset" {
+			return
+		}
+		settings = append(settings, "-"+f.Name+"="+shellQuote(f.Value.String()))
+	})
+	fmt.Fprintln(os.Stderr, "config:", strings.Join(settings, " "))
+}
+
+// shellQuote single-quotes a value for a POSIX shell unless it is plain, so
+// globs and variables in it aren't expanded when the echoed config is run.
+func shellQuote(value string) string {
+	plain := value != ""
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_./+=,-", r)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestReadYAMLConfig(t *testing.T) {
+	settings, err := readYAMLConfig([]byte(`# runs
+---
+input: "runs #3.json" # quoted
+output: 'it''s #1'
+construct: regret # unquoted
+solution: a#b
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"input":     "runs #3.json",
+		"output":    "it's #1",
+		"construct": "regret",
+		"solution":  "a#b",
+	} {
+		if got := settings[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	for _, text := range []string{
+		"input:",
+		"input: # comment",
+		`input: "runs #3.json`,
+		`input: "runs" 3`,
+	} {
+		if _, err := readYAMLConfig([]byte(text)); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for value, want := range map[string]string{
+		"":                   "''",
+		"sequential":         "sequential",
+		"runs/a-1.json":      "runs/a-1.json",
+		"/root/*.nomatch":    "'/root/*.nomatch'",
+		"x$HOME":             "'x$HOME'",
+		"runs #3.json":       "'runs #3.json'",
+		"it's":               `'it'\''s'`,
+		"width=10,width=100": "width=10,width=100",
+	} {
+		if got := shellQuote(value); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
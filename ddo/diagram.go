@@ -5,6 +5,10 @@ type Diagram struct {
 	Layer   *Layer
 	Mergers []Merger
 	Width   uint
+
+	// Stop, if set, is checked as each layer is built. Once it returns
+	// true, Next leaves the current layer in place.
+	Stop func() bool
 }
 
 // CreateDiagram makes a Decision Diagram that minimizes some objective.
@@ -18,7 +22,9 @@ func CreateDiagram(state State, mergers []Merger, width uint) *Diagram {
 
 // Next returns the next layer of a Diagram.
 func (d *Diagram) Next(inferenceDual State, incumbent State) *Layer {
-	d.Layer = d.Layer.Next(inferenceDual, incumbent)
+	if next := d.Layer.next(inferenceDual, incumbent, d.Stop); next != nil {
+		d.Layer = next
+	}
 	return d.Layer
 }
 
@@ -42,9 +42,18 @@ func (l *Layer) IsEmpty() bool {
 
 // Next builds the next Layer in a Diagram.
 func (l *Layer) Next(inferenceDual State, incumbent State) *Layer {
+	return l.next(inferenceDual, incumbent, nil)
+}
+
+// next builds the next Layer, or returns nil if stop is set and returns
+// true before the Layer is done.
+func (l *Layer) next(inferenceDual State, incumbent State, stop func() bool) *Layer {
 	size := 0
 	nextStateSlices := make([][]State, 0, len(l.States))
 	for _, state := range l.States {
+		if stop != nil && stop() {
+			return nil
+		}
 		next := state.Next(inferenceDual, incumbent)
 		nextStateSlices = append(nextStateSlices, next)
 		size += len(next)
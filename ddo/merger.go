@@ -8,7 +8,8 @@ import (
 type Merger func(states []State, width uint) []State
 
 // MaxCostRestrictionMerger removes the max cost states and returns no more than width states.
+// States with equal costs keep the order they were generated in.
 func MaxCostRestrictionMerger(states []State, width uint) []State {
-	sort.Sort(ByCost(states))
+	sort.Stable(ByCost(states))
 	return states[:width]
 }
@@ -2,6 +2,7 @@ package ddo
 
 import (
 	"container/list"
+	"math"
 	"sort"
 )
 
@@ -11,6 +12,14 @@ type node struct {
 	primal int64
 }
 
+// bound returns the best known lower bound for a node.
+func (n node) bound() int64 {
+	if n.primal > n.dual {
+		return n.primal
+	}
+	return n.dual
+}
+
 type nodevec []node
 
 func (nv nodevec) Len() int {
@@ -58,6 +67,19 @@ func (q *queue) extend(r nodevec, incumbent State) {
 	}
 }
 
+// minDual returns the smallest bound over all nodes in the queue.
+func (q *queue) minDual() int64 {
+	var dual int64 = math.MaxInt64
+	for e := q.lnv.Front(); e != nil; e = e.Next() {
+		for _, n := range e.Value.(nodevec) {
+			if b := n.bound(); b < dual {
+				dual = b
+			}
+		}
+	}
+	return dual
+}
+
 func (q *queue) pop() node {
 	front := q.lnv.Front()
 	nv := front.Value.(nodevec)
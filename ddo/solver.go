@@ -2,7 +2,11 @@ package ddo
 
 //#include <time.h>
 import "C"
-import "time"
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
 
 // Solver implements a basic Branch-and-Bound.
 type Solver struct {
@@ -12,15 +16,22 @@ type Solver struct {
 	MaxMillis uint64
 	MaxNodes  uint64
 
+	// Improver, if set, is called on each new incumbent. It may return a
+	// better solved State to use as the incumbent instead, or nil.
+	Improver func(State) State
+
 	queue     *queue
 	root      State
 	incumbent State
 
-	logger    Logger
-	wallStart time.Time
-	cpuStart  C.long
-	fails     uint64
-	nodes     uint64
+	logger      Logger
+	wallStart   time.Time
+	cpuStart    C.long
+	fails       uint64
+	nodes       uint64
+	pending     int64 // Min dual bound of nodes taken from the queue but not yet bounded
+	termination Termination
+	stopped     int32 // Set atomically by Stop
 }
 
 // CreateSolver constructs a basic Branch-and-Bound solver.
@@ -31,24 +42,63 @@ func CreateSolver(root State, logger Logger) *Solver {
 		root:      root,
 		wallStart: time.Now(),
 		cpuStart:  C.clock(),
+		pending:   math.MaxInt64,
+	}
+}
+
+// SetIncumbent provides a solved State, such as one found by a heuristic,
+// as the starting incumbent. It should be called before Minimize.
+func (s *Solver) SetIncumbent(state State) {
+	if !state.IsSolved() || !s.better(state) {
+		return
 	}
+
+	s.incumbent = state
+	b := &Bounds{Root: s.root, Primal: state, label: relaxed}
+	s.logger(s.improve(b), s.Statistics())
+}
+
+// workerResult holds the bounds a worker found for its batch, or the value
+// it panicked with.
+type workerResult struct {
+	bounds []*Bounds
+	panic  interface{}
 }
 
-// Minimize runs a full optimization from the root node.
+// Minimize runs a full optimization from the root node. A panic in a
+// worker is raised again in the goroutine that called Minimize.
 func (s *Solver) Minimize() State {
 	done := false
 	for !done && s.queue.len() > 0 {
-		boundsChan := make(chan []*Bounds)
+		if s.stop() {
+			done = true
+			break
+		}
+
+		// Results are buffered, so workers can finish and exit even if
+		// search stops before their results are read.
+		results := make(chan workerResult, s.Workers)
 
+		// Workers get a snapshot of the incumbent, since this goroutine
+		// updates it while they run.
 		for i := 0; i < s.Workers; i++ {
 			states := s.batch()
-			go func(s *Solver, states []State) {
+			go func(s *Solver, states []State, incumbent State) {
+				defer func() {
+					if r := recover(); r != nil {
+						results <- workerResult{panic: r}
+					}
+				}()
+
 				b := []*Bounds{}
 				for _, state := range states {
-					b = append(b, s.bound(state))
+					if s.expired() {
+						break
+					}
+					b = append(b, s.bound(state, incumbent))
 				}
-				boundsChan <- b
-			}(s, states)
+				results <- workerResult{bounds: b}
+			}(s, states, s.incumbent)
 		}
 
 		splitstates := nodevec{}
@@ -58,7 +108,12 @@ func (s *Solver) Minimize() State {
 				break
 			}
 
-			for _, b := range <-boundsChan {
+			result := <-results
+			if result.panic != nil {
+				panic(result.panic)
+			}
+
+			for _, b := range result.bounds {
 				if b.IsFailed() {
 					s.fails++
 					continue
@@ -67,7 +122,7 @@ func (s *Solver) Minimize() State {
 
 				if b.betterThan(s.incumbent) {
 					s.incumbent = b.Primal
-					s.logger(b, Statistics{s.elapsedSeconds(), s.elapsedCPU(), false, s.fails, s.nodes})
+					s.logger(s.improve(b), s.Statistics())
 				}
 
 				if b.IsRelaxed() {
@@ -84,10 +139,21 @@ func (s *Solver) Minimize() State {
 			}
 		}
 		s.queue.extend(splitstates, s.incumbent)
+		if !done {
+			s.pending = math.MaxInt64
+		}
+	}
+
+	if done {
+		s.termination = s.limit()
+	} else if s.incumbent != nil {
+		s.termination = Optimal
+	} else {
+		s.termination = Infeasible
 	}
 
 	// If we proved optimality, then say so.
-	if s.incumbent != nil && !s.stop() {
+	if s.termination == Optimal {
 		s.logger(
 			&Bounds{
 				Root:           s.incumbent,
@@ -96,13 +162,38 @@ func (s *Solver) Minimize() State {
 				Primal:         s.incumbent,
 				label:          exact,
 			},
-			Statistics{s.elapsedSeconds(), s.elapsedCPU(), true, s.fails, s.nodes},
+			s.Statistics(),
 		)
 	}
 
 	return s.incumbent
 }
 
+// Statistics returns execution information for the solver so far.
+func (s *Solver) Statistics() Statistics {
+	return Statistics{
+		ClockSeconds: s.elapsedSeconds(),
+		CPUSeconds:   s.elapsedCPU(),
+		Optimal:      s.termination == Optimal,
+		Fails:        s.fails,
+		Nodes:        s.nodes,
+		DualBound:    s.dualBound(),
+		Termination:  s.termination,
+	}
+}
+
+// dualBound returns the smallest bound over open nodes and the incumbent.
+func (s *Solver) dualBound() int64 {
+	bound := s.pending
+	if dual := s.queue.minDual(); dual < bound {
+		bound = dual
+	}
+	if s.incumbent != nil && s.incumbent.Cost() < bound {
+		bound = s.incumbent.Cost()
+	}
+	return bound
+}
+
 func (s *Solver) batch() []State {
 	size := int(s.Batch)
 	if size < 1 {
@@ -116,10 +207,14 @@ func (s *Solver) batch() []State {
 
 	i := 0
 	for len(states) < size && i < s.queue.len() {
-		state := s.queue.pop().state
+		n := s.queue.pop()
+		state := n.state
 
 		if s.incumbent == nil || state.Cost() < s.incumbent.Cost() {
 			states = append(states, state)
+			if dual := n.bound(); dual < s.pending {
+				s.pending = dual
+			}
 		} else {
 			s.fails++
 		}
@@ -128,8 +223,11 @@ func (s *Solver) batch() []State {
 	return states
 }
 
-// Bound solves a relaxation and then a restriction based on the current Diagram state.
-func (s *Solver) bound(state State) *Bounds {
+// Bound solves a relaxation and then a restriction based on the current
+// Diagram state, pruning with an incumbent. If search is stopped or runs
+// out of time, it returns from the last complete layers as if the
+// restriction were cut off.
+func (s *Solver) bound(state State, incumbent State) *Bounds {
 	dualBound := state.Cost()
 
 	var inferenceDual State
@@ -139,16 +237,25 @@ func (s *Solver) bound(state State) *Bounds {
 	inferenceDiagram := state.Infer()
 	relaxationDiagram := state.Relax()
 	restrictionDiagram := state.Restrict()
+	for _, d := range []*Diagram{inferenceDiagram, relaxationDiagram, restrictionDiagram} {
+		if d != nil {
+			d.Stop = s.expired
+		}
+	}
 
 	// Construct new layers for all diagrams until the restriction is done.
 	for !restrictionDiagram.IsDone() {
+		if s.expired() {
+			return &Bounds{state, inferenceDual, relaxationDual, state, relaxed}
+		}
+
 		if inferenceDiagram != nil {
 			if inferenceDiagram.Layer.IsEmpty() {
 				return &Bounds{state, inferenceDual, relaxationDual, primal, failed}
 			}
 
 			inferenceDual = inferenceDiagram.Layer.Best()
-			if s.worse(inferenceDual) {
+			if worse(inferenceDual, incumbent) {
 				return &Bounds{state, inferenceDual, relaxationDual, primal, failed}
 			}
 
@@ -163,7 +270,7 @@ func (s *Solver) bound(state State) *Bounds {
 			}
 
 			relaxationDual = relaxationDiagram.Layer.Best()
-			if s.worse(relaxationDual) {
+			if worse(relaxationDual, incumbent) {
 				return &Bounds{state, inferenceDual, relaxationDual, primal, failed}
 			}
 
@@ -173,17 +280,17 @@ func (s *Solver) bound(state State) *Bounds {
 		}
 
 		primal = restrictionDiagram.Layer.Best()
-		if restrictionDiagram.Layer.IsExact && s.worse(primal) {
+		if restrictionDiagram.Layer.IsExact && worse(primal, incumbent) {
 			return &Bounds{state, inferenceDual, relaxationDual, primal, failed}
 		}
 
 		if inferenceDiagram != nil {
-			inferenceDiagram.Next(inferenceDual, s.incumbent)
+			inferenceDiagram.Next(inferenceDual, incumbent)
 		}
 		if relaxationDiagram != nil {
-			relaxationDiagram.Next(inferenceDual, s.incumbent)
+			relaxationDiagram.Next(inferenceDual, incumbent)
 		}
-		restrictionDiagram.Next(inferenceDual, s.incumbent)
+		restrictionDiagram.Next(inferenceDual, incumbent)
 	}
 
 	if primal.IsSolved() {
@@ -204,8 +311,42 @@ func (s *Solver) bound(state State) *Bounds {
 	return &Bounds{state, inferenceDual, relaxationDual, state, relaxed}
 }
 
-func (s *Solver) stop() bool {
+// improve runs the Improver on a new incumbent. If it finds something
+// better, that becomes the incumbent, and improve returns bounds with the
+// improved primal state for logging.
+func (s *Solver) improve(b *Bounds) *Bounds {
+	if s.Improver == nil {
+		return b
+	}
+
+	improved := s.Improver(b.Primal)
+	if improved == nil || !improved.IsSolved() || improved.Cost() >= b.Primal.Cost() {
+		return b
+	}
+
+	s.incumbent = improved
+	return &Bounds{b.Root, b.InferenceDual, b.RelaxationDual, improved, b.label}
+}
+
+// Stop ends search early, as if a limit had been reached. It is safe to
+// call from another goroutine, such as a signal handler.
+func (s *Solver) Stop() {
+	atomic.StoreInt32(&s.stopped, 1)
+}
+
+// limit returns the limit that stopped search.
+func (s *Solver) limit() Termination {
+	if atomic.LoadInt32(&s.stopped) != 0 {
+		return Interrupted
+	}
 	if s.MaxMillis > 0 && s.elapsedMilliSeconds() >= float64(s.MaxMillis) {
+		return TimeLimit
+	}
+	return NodeLimit
+}
+
+func (s *Solver) stop() bool {
+	if s.expired() {
 		return true
 	}
 	if s.MaxNodes > 0 && uint64(s.nodes+s.fails) >= s.MaxNodes {
@@ -214,12 +355,22 @@ func (s *Solver) stop() bool {
 	return false
 }
 
+// expired returns true if search was stopped or its time limit has passed.
+// Unlike stop, it is safe to call from workers.
+func (s *Solver) expired() bool {
+	if atomic.LoadInt32(&s.stopped) != 0 {
+		return true
+	}
+	return s.MaxMillis > 0 && s.elapsedMilliSeconds() >= float64(s.MaxMillis)
+}
+
 func (s *Solver) better(state State) bool {
 	return s.incumbent == nil || state.Cost() < s.incumbent.Cost()
 }
 
-func (s *Solver) worse(state State) bool {
-	return s.incumbent != nil && state.Cost() >= s.incumbent.Cost()
+// worse returns true if a state can't improve on an incumbent.
+func worse(state State, incumbent State) bool {
+	return incumbent != nil && state.Cost() >= incumbent.Cost()
 }
 
 func (s *Solver) elapsedMilliSeconds() float64 {
@@ -7,4 +7,42 @@ type Statistics struct {
 	Optimal      bool
 	Fails        uint64
 	Nodes        uint64
+	DualBound    int64       // Lower bound on the optimal cost
+	Termination  Termination // Why search ended, or Running
+}
+
+// Termination gives the reason search ended.
+type Termination int
+
+const (
+	// Running means search hasn't ended yet.
+	Running Termination = iota
+	// Optimal means the incumbent was proven optimal.
+	Optimal
+	// Infeasible means search ended without finding a solution.
+	Infeasible
+	// TimeLimit means search reached its time limit.
+	TimeLimit
+	// NodeLimit means search reached its node limit.
+	NodeLimit
+	// Interrupted means search was stopped early by a call to Stop.
+	Interrupted
+)
+
+func (t Termination) String() string {
+	switch t {
+	case Running:
+		return "running"
+	case Optimal:
+		return "optimal"
+	case Infeasible:
+		return "infeasible"
+	case TimeLimit:
+		return "time-limit"
+	case NodeLimit:
+		return "node-limit"
+	case Interrupted:
+		return "interrupted"
+	}
+	return "unknown"
 }
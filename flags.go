@@ -4,84 +4,164 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solve"
 )
 
 type flags struct {
-	_batch     *int
-	_cpuprof   *string
-	_form      *string
-	_infer     *string
-	_input     *string
-	_maxmillis *uint64
-	_maxnodes  *uint64
-	_memprof   *string
-	_ordering  *string
-	_output    *string
-	_relax     *string
-	_seed      *int64
-	_verbosity *uint
-	_width     *uint
-	_workers   *int
+	_batch      *int
+	_config     *string
+	_construct  *string
+	_cpuprof    *string
+	_debug      *bool
+	_form       *string
+	_infer      *string
+	_input      *string
+	_lns        *string
+	_lnsnodes   *uint64
+	_lnspairs   *int
+	_local      *bool
+	_maxmillis  *uint64
+	_maxnodes   *uint64
+	_memprof    *string
+	_ordering   *string
+	_output     *string
+	_parallel   *int
+	_preset     *string
+	_preprocess *bool
+	_relax      *string
+	_seed       *int64
+	_solution   *string
+	_sweep      *string
+	_verbosity  *uint
+	_width      *uint
+	_workers    *int
 }
 
 func parseFlags() *flags {
 	flags := &flags{
-		_batch:     flag.Int("batch", 1, "batch size for parallelization"),
-		_cpuprof:   flag.String("cpuprof", "", "cpu profile output"),
-		_form:      flag.String("form", "", "formulation {sequential, successor}"),
-		_infer:     flag.String("infer", "none", "inference dual {ap, none}"),
-		_input:     flag.String("input", "-", "input json file"),
-		_maxmillis: flag.Uint64("maxmillis", 0, "max milliseconds for search"),
-		_maxnodes:  flag.Uint64("maxnodes", 0, "max nodes and fails for search"),
-		_memprof:   flag.String("memprof", "", "mem profile output"),
-		_ordering:  flag.String("ordering", "", "successor={greedy, input, regret}"),
-		_output:    flag.String("output", "", "{csv, csv-header}"),
-		_relax:     flag.String("relax", "none", "relaxation dual sequential={dd, none}"),
-		_verbosity: flag.Uint("verbosity", 0, "solver verbosity (0 = quiet, 1 = solutions, 2 = layer construction)"),
-		_width:     flag.Uint("width", 0, "diagram width"),
-		_workers:   flag.Int("workers", 1, "number of workers"),
+		_batch:      flag.Int("batch", 1, "batch size for parallelization"),
+		_config:     flag.String("config", "", "yaml or json file of settings, overridden by flags"),
+		_construct:  flag.String("construct", "", "initial incumbent heuristic {nearest, cheapest, regret}"),
+		_cpuprof:    flag.String("cpuprof", "", "cpu profile output"),
+		_debug:      flag.Bool("debug", false, "verify every incumbent"),
+		_form:       flag.String("form", "", formUsage()),
+		_infer:      flag.String("infer", "none", inferUsage()),
+		_input:      flag.String("input", "-", "input json file, or a directory or glob of them"),
+		_lns:        flag.String("lns", "", "large neighborhood search for sequential form {window, random}"),
+		_lnsnodes:   flag.Uint64("lnsnodes", 1000, "max nodes and fails for each lns sub-problem"),
+		_lnspairs:   flag.Int("lnspairs", 4, "pickup and delivery pairs freed in each lns neighborhood"),
+		_local:      flag.Bool("local", false, "improve incumbents with local search"),
+		_maxmillis:  flag.Uint64("maxmillis", 0, "max milliseconds for search"),
+		_maxnodes:   flag.Uint64("maxnodes", 0, "max nodes and fails for search"),
+		_memprof:    flag.String("memprof", "", "mem profile output"),
+		_ordering:   flag.String("ordering", "", orderingUsage()),
+		_output:     flag.String("output", "", "{csv, csv-header, json, jsonl}"),
+		_parallel:   flag.Int("parallel", 1, "instances solved at once in batch mode"),
+		_preset:     flag.String("preset", "", "named settings {fast-realtime, prove-optimal}, overridden by config and flags"),
+		_preprocess: flag.Bool("preprocess", false, "eliminate arcs by precedence and reduced cost"),
+		_relax:      flag.String("relax", "none", relaxUsage()),
+		_seed:       flag.Int64("seed", 0, "random seed"),
+		_solution:   flag.String("solution", "", "file to write the best solution to at exit"),
+		_sweep:      flag.String("sweep", "", "batch parameter combinations (e.g. form=sequential,infer=ap;form=successor,ordering=greedy)"),
+		_verbosity:  flag.Uint("verbosity", 0, "solver verbosity (0 = quiet, 1 = solutions, 2 = layer construction)"),
+		_width:      flag.Uint("width", 0, "diagram width"),
+		_workers:    flag.Int("workers", 1, "number of workers"),
 	}
+	flag.Usage = usage
 	flag.Parse()
+
+	if err := applyConfig(*flags._config, *flags._preset); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	return flags
 }
 
+// validate checks that flags describe a valid run, and exits otherwise.
 func (f *flags) validate() {
-	if *f._batch < 1 {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("batch size must be >= 1"))
+	if err := f.check(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+}
 
-	if f.form() != "sequential" && f.form() != "successor" {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("valid formulation required"))
-		os.Exit(1)
-	}
+// override returns a copy of flags with form, infer, relax, ordering, or
+// width replaced by values given as key=value pairs.
+func (f *flags) override(settings string) (*flags, error) {
+	g := *f
+	for _, setting := range strings.Split(settings, ",") {
+		kv := strings.SplitN(strings.TrimSpace(setting), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid setting %q", setting)
+		}
+		key, value := kv[0], kv[1]
 
-	if f.infer() != "none" && f.infer() != "ap" {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("invalid inference dual form"))
-		os.Exit(1)
+		switch key {
+		case "form":
+			g._form = &value
+		case "infer":
+			g._infer = &value
+		case "relax":
+			g._relax = &value
+		case "ordering":
+			g._ordering = &value
+		case "width":
+			w, err := strconv.ParseUint(value, 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid width %q", value)
+			}
+			width := uint(w)
+			g._width = &width
+		default:
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
 	}
+	return &g, nil
+}
 
-	orderings := map[string]map[string]bool{
-		"successor": map[string]bool{
-			"input":  true,
-			"greedy": true,
-			"regret": true,
-		},
+// check returns an error if flags don't describe a valid run.
+func (f *flags) check() error {
+	options := f.options(nil)
+	if err := options.Validate(); err != nil {
+		return err
 	}
 
-	if orderings[f.form()] != nil && !orderings[f.form()][f.ordering()] {
-		fmt.Fprintln(os.Stderr, fmt.Errorf(f.form()+" form requires valid decision ordering"))
-		os.Exit(1)
+	outputs := map[string]bool{"": true, "csv": true, "csv-header": true, "json": true, "jsonl": true}
+	if !outputs[f.output()] {
+		return fmt.Errorf("invalid output format")
 	}
 
-	if f.relax() != "none" && (f.form() != "sequential" && f.relax() != "dd") {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("invalid relaxation dual form"))
-		os.Exit(1)
-	}
+	return nil
+}
 
-	if *f._workers < 1 {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("workers must be >= 1"))
-		os.Exit(1)
+// options converts flags to options for solve.Solve. Each new incumbent
+// is passed to logger.
+func (f *flags) options(logger ddo.Logger) solve.Options {
+	return solve.Options{
+		Form:       f.form(),
+		Infer:      f.infer(),
+		Relax:      f.relax(),
+		Ordering:   f.ordering(),
+		Width:      f.width(),
+		Batch:      f.batch(),
+		Workers:    f.workers(),
+		MaxMillis:  f.maxmillis(),
+		MaxNodes:   f.maxnodes(),
+		Verbosity:  f.verbosity(),
+		Construct:  f.construct(),
+		Local:      f.local(),
+		Preprocess: f.preprocess(),
+		Debug:      f.debug(),
+		LNS:        f.lns(),
+		LNSNodes:   f.lnsnodes(),
+		LNSPairs:   f.lnspairs(),
+		Seed:       f.seed(),
+		Logger:     logger,
+		Log:        os.Stderr,
 	}
 }
 
@@ -89,10 +169,18 @@ func (f *flags) batch() int {
 	return *f._batch
 }
 
+func (f *flags) construct() string {
+	return *f._construct
+}
+
 func (f *flags) cpuprof() string {
 	return *f._cpuprof
 }
 
+func (f *flags) debug() bool {
+	return *f._debug
+}
+
 func (f *flags) form() string {
 	return *f._form
 }
@@ -105,6 +193,22 @@ func (f *flags) input() string {
 	return *f._input
 }
 
+func (f *flags) lns() string {
+	return *f._lns
+}
+
+func (f *flags) lnsnodes() uint64 {
+	return *f._lnsnodes
+}
+
+func (f *flags) lnspairs() int {
+	return *f._lnspairs
+}
+
+func (f *flags) local() bool {
+	return *f._local
+}
+
 func (f *flags) maxmillis() uint64 {
 	return *f._maxmillis
 }
@@ -125,10 +229,30 @@ func (f *flags) output() string {
 	return *f._output
 }
 
+func (f *flags) parallel() int {
+	return *f._parallel
+}
+
+func (f *flags) preprocess() bool {
+	return *f._preprocess
+}
+
 func (f *flags) relax() string {
 	return *f._relax
 }
 
+func (f *flags) seed() int64 {
+	return *f._seed
+}
+
+func (f *flags) solution() string {
+	return *f._solution
+}
+
+func (f *flags) sweep() string {
+	return *f._sweep
+}
+
 func (f *flags) verbosity() uint {
 	return *f._verbosity
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd/generate"
+)
+
+// generateProblem implements the generate subcommand, which writes a
+// random problem as JSON.
+func generateProblem(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	name := fs.String("name", "", "instance name (default: <distribution>-<pairs>-<seed>)")
+	pairs := fs.Int("pairs", 5, "number of pickup and delivery pairs")
+	distribution := fs.String("distribution", "uniform", "node locations {uniform, clustered, depot-centric}")
+	metric := fs.String("metric", "euclidean", "metric {euclidean, ceil-euclidean, manhattan, haversine}")
+	asymmetry := fs.Float64("asymmetry", 0, "scale arc costs by a random factor in [1, 1+asymmetry]")
+	seed := fs.Int64("seed", 0, "random seed")
+	output := fs.String("output", "-", "output json file")
+	fs.Parse(args)
+
+	problem, err := generate.Generate(generate.Options{
+		Name:         *name,
+		Pairs:        *pairs,
+		Distribution: *distribution,
+		Metric:       *metric,
+		Asymmetry:    *asymmetry,
+		Seed:         *seed,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	b, err := json.MarshalIndent(problem, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	b = append(b, '\n')
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		err = ioutil.WriteFile(*output, b, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
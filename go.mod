@@ -1,5 +1,3 @@
 module github.com/ryanjoneil/tsppd-dd
 
-go 1.12
-
-require github.com/ryanjoneil/ap v0.0.0-20180518003621-34445c39fc17
+go 1.18
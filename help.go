@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+func formUsage() string {
+	names := []string{}
+	for _, f := range registry.Formulations() {
+		names = append(names, f.Name)
+	}
+	return "formulation {" + strings.Join(names, ", ") + "}"
+}
+
+func inferUsage() string {
+	names := []string{}
+	for _, d := range registry.InferenceDuals() {
+		names = append(names, d.Name)
+	}
+	names = append(names, "none")
+	return "inference dual {" + strings.Join(names, ", ") + "}, or several joined by + (e.g. ap+arb)"
+}
+
+func relaxUsage() string {
+	forms := []string{}
+	for _, f := range registry.Formulations() {
+		if len(f.Relaxations) == 0 {
+			continue
+		}
+		names := []string{}
+		for _, r := range f.Relaxations {
+			names = append(names, r.Name)
+		}
+		names = append(names, "none")
+		forms = append(forms, f.Name+"={"+strings.Join(names, ", ")+"}")
+	}
+	return "relaxation dual " + strings.Join(forms, " ")
+}
+
+func orderingUsage() string {
+	forms := []string{}
+	for _, f := range registry.Formulations() {
+		if len(f.Orderings) == 0 {
+			continue
+		}
+		names := []string{}
+		for _, o := range f.Orderings {
+			names = append(names, o.Name)
+		}
+		forms = append(forms, f.Name+"={"+strings.Join(names, ", ")+"}")
+	}
+	return strings.Join(forms, " ")
+}
+
+// usage writes the flag defaults followed by a description of every
+// registered component.
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "\nFormulations:")
+	for _, f := range registry.Formulations() {
+		fmt.Fprintf(out, "  %-15s %s\n", f.Name, f.Description)
+		for _, r := range f.Relaxations {
+			fmt.Fprintf(out, "    -relax %-18s %s\n", r.Name, r.Description)
+		}
+		for _, o := range f.Orderings {
+			description := o.Description
+			if o.RequiresInference {
+				description += ", requires -infer"
+			}
+			fmt.Fprintf(out, "    -ordering %-15s %s\n", o.Name, description)
+		}
+	}
+
+	fmt.Fprintln(out, "\nInference duals:")
+	for _, d := range registry.InferenceDuals() {
+		fmt.Fprintf(out, "  %-15s %s\n", d.Name, d.Description)
+	}
+}
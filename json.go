@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// jsonRecord is written for each new incumbent, followed by a summary
+// record once search ends.
+type jsonRecord struct {
+	Type        string         `json:"type"` // "solution" or "summary"
+	Instance    jsonInstance   `json:"instance"`
+	Options     jsonOptions    `json:"options"`
+	Statistics  jsonStatistics `json:"statistics"`
+	Primal      *int64         `json:"primal"`
+	Dual        int64          `json:"dual"`
+	Gap         *float64       `json:"gap"`
+	Termination string         `json:"termination,omitempty"`
+	Path        []string       `json:"path"`
+	Arcs        []jsonArc      `json:"arcs"`
+}
+
+type jsonInstance struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+type jsonOptions struct {
+	Form       string `json:"form"`
+	Infer      string `json:"infer"`
+	Relax      string `json:"relax"`
+	Ordering   string `json:"ordering"`
+	Width      uint   `json:"width"`
+	Batch      int    `json:"batch"`
+	Workers    int    `json:"workers"`
+	MaxMillis  uint64 `json:"maxmillis"`
+	MaxNodes   uint64 `json:"maxnodes"`
+	Construct  string `json:"construct"`
+	Local      bool   `json:"local"`
+	Preprocess bool   `json:"preprocess"`
+	LNS        string `json:"lns"`
+	LNSNodes   uint64 `json:"lnsnodes"`
+	LNSPairs   int    `json:"lnspairs"`
+	Seed       int64  `json:"seed"`
+}
+
+type jsonStatistics struct {
+	Clock   float64 `json:"clock"`
+	CPU     float64 `json:"cpu"`
+	Optimal bool    `json:"optimal"`
+	Nodes   uint64  `json:"nodes"`
+	Fails   uint64  `json:"fails"`
+}
+
+type jsonArc struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Cost       int64  `json:"cost"`
+	Cumulative int64  `json:"cumulative"`
+}
+
+func (o *output) createJSONRecord(recordType string, primal ddo.State, stats ddo.Statistics) *jsonRecord {
+	f := o.flags
+	record := &jsonRecord{
+		Type:     recordType,
+		Instance: jsonInstance{Name: o.problem.Name, Size: len(o.problem.Nodes)},
+		Options: jsonOptions{
+			Form:       f.form(),
+			Infer:      f.infer(),
+			Relax:      f.relax(),
+			Ordering:   f.ordering(),
+			Width:      f.width(),
+			Batch:      f.batch(),
+			Workers:    f.workers(),
+			MaxMillis:  f.maxmillis(),
+			MaxNodes:   f.maxnodes(),
+			Construct:  f.construct(),
+			Local:      f.local(),
+			Preprocess: f.preprocess(),
+			LNS:        f.lns(),
+			LNSNodes:   f.lnsnodes(),
+			LNSPairs:   f.lnspairs(),
+			Seed:       f.seed(),
+		},
+		Statistics: jsonStatistics{
+			Clock:   stats.ClockSeconds,
+			CPU:     stats.CPUSeconds,
+			Optimal: stats.Optimal,
+			Nodes:   stats.Nodes,
+			Fails:   stats.Fails,
+		},
+		Dual: stats.DualBound,
+		Path: []string{},
+		Arcs: []jsonArc{},
+	}
+
+	if primal == nil {
+		return record
+	}
+
+	cost := primal.Cost()
+	record.Primal = &cost
+	if cost > 0 {
+		gap := float64(cost-stats.DualBound) / float64(cost)
+		record.Gap = &gap
+	}
+
+	record.Path = primal.(tsppd.State).Solution().Path
+	var cumulative int64
+	for i := 1; i < len(record.Path); i++ {
+		arc, _ := o.problem.Cost(record.Path[i-1], record.Path[i])
+		cumulative += arc
+		record.Arcs = append(record.Arcs, jsonArc{
+			From:       record.Path[i-1],
+			To:         record.Path[i],
+			Cost:       arc,
+			Cumulative: cumulative,
+		})
+	}
+
+	return record
+}
+
+// writeJSON writes a record immediately as a line of JSON for jsonl output,
+// or saves it to write with the others as a JSON array for json output.
+func (o *output) writeJSON(record *jsonRecord) {
+	if o.flags.output() == "jsonl" {
+		json.NewEncoder(os.Stdout).Encode(record)
+	} else {
+		o.records = append(o.records, record)
+	}
+}
+
+func (o *output) flushJSON() {
+	if o.flags.output() == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(o.records)
+	}
+}
@@ -2,30 +2,36 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 
 	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solve"
 )
 
 func readProblem(input string) *tsppd.Problem {
-	var b []byte
-	var err error
-	if input == "-" {
-		b, err = ioutil.ReadAll(os.Stdin)
-	} else {
-		b, err = ioutil.ReadFile(input)
-	}
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
 	}
 
-	problem, err := tsppd.Decode(b)
+	problem, err := solve.ReadProblem(r)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		if errs, ok := err.(tsppd.ValidationError); ok {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", input, e)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", input, err)
+		}
 		os.Exit(1)
 	}
 
-	return &problem
+	return problem
 }
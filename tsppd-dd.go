@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime/pprof"
+	"syscall"
 
 	"github.com/ryanjoneil/tsppd-dd/ddo"
-	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/sequential"
-	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/successor"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solve"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		verify(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		generateProblem(os.Args[2:])
+		return
+	}
+
 	flags := parseFlags()
-	flags.validate()
+	echoConfig()
 
 	if flags.cpuprof() != "" {
 		f, err := os.Create(flags.cpuprof())
@@ -24,37 +35,41 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	problem := readProblem(flags.input())
+	if isBatch(flags) {
+		runBatch(flags)
+	} else {
+		flags.validate()
+		problem := readProblem(flags.input())
+		output := createOutput(flags, problem)
 
-	var root ddo.State
-	if flags.form() == "sequential" {
-		root = sequential.CreateRootState(
-			problem,
-			flags.infer(),
-			flags.relax(),
-			flags.ordering(),
-			flags.width(),
-			flags.verbosity(),
-		)
-	} else if flags.form() == "successor" {
-		root = successor.CreateRootState(
-			problem,
-			flags.infer(),
-			flags.relax(),
-			flags.ordering(),
-			flags.width(),
-			flags.verbosity(),
-		)
-	}
+		// The solution file is rewritten for each new incumbent, so it
+		// holds the best route found even if the process is killed.
+		logger := output.write
+		if flags.solution() != "" {
+			logger = func(b *ddo.Bounds, stats ddo.Statistics) {
+				output.write(b, stats)
+				if err := writeSolution(flags.solution(), problem, b.Primal, stats); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+		}
 
-	output := createOutput(flags, problem)
-	solver := ddo.CreateSolver(root, output.write)
-	solver.Batch = flags.batch()
-	solver.Workers = flags.workers()
-	solver.MaxMillis = flags.maxmillis()
-	solver.MaxNodes = flags.maxnodes()
+		ctx, cancel := context.WithCancel(context.Background())
+		stopOnSignal(cancel)
+		result, err := solve.Solve(ctx, problem, flags.options(logger))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		output.finish(result.Incumbent, result.Statistics)
 
-	solver.Minimize()
+		if flags.solution() != "" {
+			if err := writeSolution(flags.solution(), problem, result.Incumbent, result.Statistics); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
 
 	if flags.memprof() != "" {
 		f, err := os.Create(flags.memprof())
@@ -66,3 +81,16 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 }
+
+// stopOnSignal calls stop on the first SIGINT or SIGTERM, so search can
+// end gracefully and write its results. A second signal kills the process,
+// leaving the solution file as of the last incumbent.
+func stopOnSignal(stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		stop()
+	}()
+}
@@ -0,0 +1,36 @@
+// Package construct builds feasible TSPPD solutions quickly, to give
+// search a starting incumbent.
+package construct
+
+import (
+	"fmt"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// Heuristic constructs a feasible solution to a problem, or returns nil if
+// it fails to find one.
+type Heuristic func(problem *tsppd.Problem) *tsppd.Solution
+
+var heuristics = map[string]Heuristic{
+	"nearest":  Nearest,
+	"cheapest": CheapestInsertion,
+	"regret":   RegretInsertion,
+}
+
+// Lookup returns a Heuristic by name.
+func Lookup(name string) (Heuristic, error) {
+	heuristic, ok := heuristics[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown construction heuristic %q", name)
+	}
+	return heuristic, nil
+}
+
+func createSolution(problem *tsppd.Problem, route []int) *tsppd.Solution {
+	path := make([]string, len(route))
+	for i, index := range route {
+		path[i] = problem.Nodes[index]
+	}
+	return &tsppd.Solution{Problem: problem, Path: path}
+}
@@ -0,0 +1,132 @@
+package construct
+
+import (
+	"math"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// insertion is a position to insert a pickup and delivery pair into a
+// route: the pickup goes after route[i] and the delivery after route[j].
+type insertion struct {
+	i, j int
+	cost int64
+}
+
+// CheapestInsertion starts with a route from the start to the end and
+// repeatedly inserts the pair that increases its cost the least.
+func CheapestInsertion(problem *tsppd.Problem) *tsppd.Solution {
+	return insert(problem, func(best, second insertion) int64 {
+		return -best.cost
+	})
+}
+
+// RegretInsertion starts with a route from the start to the end and
+// repeatedly inserts the pair with the largest difference between the
+// cost of its best and second best insertions.
+func RegretInsertion(problem *tsppd.Problem) *tsppd.Solution {
+	return insert(problem, func(best, second insertion) int64 {
+		if second.cost == math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return second.cost - best.cost
+	})
+}
+
+// insert builds a route by inserting the pair with the highest priority at
+// its cheapest position until all pairs are routed.
+func insert(problem *tsppd.Problem, priority func(best, second insertion) int64) *tsppd.Solution {
+	pickups := []int{}
+	for index := range problem.Nodes {
+		if problem.IsPickupIndex(index) {
+			pickups = append(pickups, index)
+		}
+	}
+
+	route := []int{problem.StartIndex(), problem.EndIndex()}
+	for len(pickups) > 0 {
+		selected := -1
+		var selectedPriority int64
+		var selectedInsertion insertion
+
+		for k, pickup := range pickups {
+			best, second := bestInsertions(problem, route, pickup)
+			if best.cost == math.MaxInt64 {
+				return nil
+			}
+
+			p := priority(best, second)
+			if selected < 0 || p > selectedPriority {
+				selected = k
+				selectedPriority = p
+				selectedInsertion = best
+			}
+		}
+
+		pickup := pickups[selected]
+		route = insertPair(route, pickup, problem.PairIndex(pickup), selectedInsertion)
+		pickups = append(pickups[:selected], pickups[selected+1:]...)
+	}
+
+	return createSolution(problem, route)
+}
+
+// bestInsertions returns the cheapest and second cheapest feasible
+// insertions of a pair into a route. Costs are math.MaxInt64 if there are
+// no such insertions.
+func bestInsertions(problem *tsppd.Problem, route []int, pickup int) (insertion, insertion) {
+	delivery := problem.PairIndex(pickup)
+	best := insertion{cost: math.MaxInt64}
+	second := insertion{cost: math.MaxInt64}
+
+	arc := func(index1, index2 int) (int64, bool) {
+		return problem.CostIndex(index1, index2), problem.IsFeasibleIndex(index1, index2)
+	}
+
+	for i := 0; i < len(route)-1; i++ {
+		c1, ok1 := arc(route[i], pickup)
+		if !ok1 {
+			continue
+		}
+
+		for j := i; j < len(route)-1; j++ {
+			var cost int64
+			if i == j {
+				c2, ok2 := arc(pickup, delivery)
+				c3, ok3 := arc(delivery, route[i+1])
+				if !ok2 || !ok3 {
+					continue
+				}
+				cost = c1 + c2 + c3 - problem.CostIndex(route[i], route[i+1])
+			} else {
+				c2, ok2 := arc(pickup, route[i+1])
+				c3, ok3 := arc(route[j], delivery)
+				c4, ok4 := arc(delivery, route[j+1])
+				if !ok2 || !ok3 || !ok4 {
+					continue
+				}
+				cost = c1 + c2 - problem.CostIndex(route[i], route[i+1]) +
+					c3 + c4 - problem.CostIndex(route[j], route[j+1])
+			}
+
+			if cost < best.cost {
+				second = best
+				best = insertion{i, j, cost}
+			} else if cost < second.cost {
+				second = insertion{i, j, cost}
+			}
+		}
+	}
+
+	return best, second
+}
+
+func insertPair(route []int, pickup, delivery int, at insertion) []int {
+	next := make([]int, 0, len(route)+2)
+	next = append(next, route[:at.i+1]...)
+	next = append(next, pickup)
+	next = append(next, route[at.i+1:at.j+1]...)
+	next = append(next, delivery)
+	next = append(next, route[at.j+1:]...)
+	return next
+}
@@ -0,0 +1,42 @@
+package construct
+
+import "github.com/ryanjoneil/tsppd-dd/tsppd"
+
+// Nearest builds a route from the start by always moving to the closest
+// node that can feasibly be visited next.
+func Nearest(problem *tsppd.Problem) *tsppd.Solution {
+	n := len(problem.Nodes)
+	visited := make([]bool, n)
+
+	route := make([]int, 0, n)
+	route = append(route, problem.StartIndex())
+	visited[problem.StartIndex()] = true
+
+	for len(route) < n-1 {
+		last := route[len(route)-1]
+
+		best := -1
+		for index := range problem.Nodes {
+			if visited[index] || index == problem.EndIndex() || !problem.IsFeasibleIndex(last, index) {
+				continue
+			}
+			if problem.IsDeliveryIndex(index) && !visited[problem.PairIndex(index)] {
+				continue
+			}
+			if best < 0 || problem.CostIndex(last, index) < problem.CostIndex(last, best) {
+				best = index
+			}
+		}
+
+		if best < 0 {
+			return nil
+		}
+		route = append(route, best)
+		visited[best] = true
+	}
+
+	if !problem.IsFeasibleIndex(route[len(route)-1], problem.EndIndex()) {
+		return nil
+	}
+	return createSolution(problem, append(route, problem.EndIndex()))
+}
@@ -0,0 +1,180 @@
+// Package generate creates random TSPPD instances of controlled size and
+// structure.
+package generate
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// Options control the instances Generate creates.
+type Options struct {
+	Name         string  // Defaults to <distribution>-<pairs>-<seed>
+	Pairs        int     // Number of pickup and delivery pairs
+	Distribution string  // uniform, clustered, or depot-centric
+	Metric       string  // Any metric supported by tsppd.LookupMetric
+	Asymmetry    float64 // Arc costs are scaled by a random factor in [1, 1+Asymmetry]
+	Seed         int64
+}
+
+// Nodes are placed in a square with this side. For the haversine metric,
+// they are instead placed in a region this many degrees across, about 10 km,
+// starting at this latitude.
+const (
+	side     = 1000.0
+	latitude = 40.0
+	degrees  = 0.1
+)
+
+// Number of pairs for each cluster in the clustered distribution, and the
+// standard deviation of nodes around cluster centers and the depot, as a
+// fraction of the side.
+const (
+	pairsPerCluster = 5
+	clusterSpread   = 0.05
+	depotSpread     = 0.1
+)
+
+var distributions = map[string]func(*rand.Rand, int) (depot []float64, pickups, deliveries [][]float64){
+	"uniform":       uniform,
+	"clustered":     clustered,
+	"depot-centric": depotCentric,
+}
+
+// Generate creates a random problem with nodes +0, -0, +1, -1, ... The
+// problem is given by its coordinates and metric, unless arc costs are
+// asymmetric, in which case its Edges matrix is given as well. It is
+// initialized like a decoded problem, and ready to solve.
+func Generate(options Options) (*tsppd.Problem, error) {
+	if options.Pairs < 1 {
+		return nil, fmt.Errorf("pairs must be >= 1")
+	}
+	if options.Asymmetry < 0 {
+		return nil, fmt.Errorf("asymmetry must be >= 0")
+	}
+	distribution, ok := distributions[options.Distribution]
+	if !ok {
+		return nil, fmt.Errorf("unknown distribution %q", options.Distribution)
+	}
+	metric, err := tsppd.LookupMetric(options.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	name := options.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%d-%d", options.Distribution, options.Pairs, options.Seed)
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	depot, pickups, deliveries := distribution(random, options.Pairs)
+
+	problem := tsppd.Problem{
+		Name:        name,
+		Nodes:       []string{tsppd.DefaultStart, tsppd.DefaultEnd},
+		Precedence:  map[string]string{},
+		Coordinates: map[string][]float64{},
+		Metric:      options.Metric,
+	}
+	problem.Coordinates[tsppd.DefaultStart] = scale(depot, options.Metric)
+	problem.Coordinates[tsppd.DefaultEnd] = scale(depot, options.Metric)
+
+	for i := 0; i < options.Pairs; i++ {
+		pickup := "+" + strconv.Itoa(i+1)
+		delivery := "-" + strconv.Itoa(i+1)
+		problem.Nodes = append(problem.Nodes, pickup, delivery)
+		problem.Precedence[pickup] = delivery
+		problem.Coordinates[pickup] = scale(pickups[i], options.Metric)
+		problem.Coordinates[delivery] = scale(deliveries[i], options.Metric)
+	}
+
+	if options.Asymmetry > 0 {
+		problem.Edges = make([][]int64, len(problem.Nodes))
+		for index1, node1 := range problem.Nodes {
+			problem.Edges[index1] = make([]int64, len(problem.Nodes))
+			for index2, node2 := range problem.Nodes {
+				if index1 == index2 {
+					continue
+				}
+				cost := float64(metric(problem.Coordinates[node1], problem.Coordinates[node2]))
+				factor := 1 + options.Asymmetry*random.Float64()
+				problem.Edges[index1][index2] = int64(math.Round(cost * factor))
+			}
+		}
+	}
+
+	return tsppd.CreateProblem(problem)
+}
+
+// uniform places every node uniformly at random.
+func uniform(random *rand.Rand, pairs int) ([]float64, [][]float64, [][]float64) {
+	depot := point(random)
+	pickups := make([][]float64, pairs)
+	deliveries := make([][]float64, pairs)
+	for i := 0; i < pairs; i++ {
+		pickups[i] = point(random)
+		deliveries[i] = point(random)
+	}
+	return depot, pickups, deliveries
+}
+
+// clustered places nodes normally around randomly placed cluster centers.
+func clustered(random *rand.Rand, pairs int) ([]float64, [][]float64, [][]float64) {
+	centers := make([][]float64, (pairs+pairsPerCluster-1)/pairsPerCluster)
+	for i := range centers {
+		centers[i] = point(random)
+	}
+	near := func() []float64 {
+		return around(random, centers[random.Intn(len(centers))], clusterSpread)
+	}
+
+	depot := near()
+	pickups := make([][]float64, pairs)
+	deliveries := make([][]float64, pairs)
+	for i := 0; i < pairs; i++ {
+		pickups[i] = near()
+		deliveries[i] = near()
+	}
+	return depot, pickups, deliveries
+}
+
+// depotCentric places the depot in the center with pickups gathered around
+// it and deliveries spread uniformly, like restaurants and customers in
+// meal delivery.
+func depotCentric(random *rand.Rand, pairs int) ([]float64, [][]float64, [][]float64) {
+	depot := []float64{0.5, 0.5}
+	pickups := make([][]float64, pairs)
+	deliveries := make([][]float64, pairs)
+	for i := 0; i < pairs; i++ {
+		pickups[i] = around(random, depot, depotSpread)
+		deliveries[i] = point(random)
+	}
+	return depot, pickups, deliveries
+}
+
+// point returns a uniform point in the unit square.
+func point(random *rand.Rand) []float64 {
+	return []float64{random.Float64(), random.Float64()}
+}
+
+// around returns a point normally distributed around a center, clamped to
+// the unit square.
+func around(random *rand.Rand, center []float64, spread float64) []float64 {
+	c := make([]float64, 2)
+	for i := range c {
+		c[i] = math.Max(0, math.Min(1, center[i]+spread*random.NormFloat64()))
+	}
+	return c
+}
+
+// scale maps a point in the unit square to coordinates for a metric.
+func scale(c []float64, metric string) []float64 {
+	if metric == "haversine" {
+		return []float64{latitude + degrees*c[0], degrees * c[1]}
+	}
+	return []float64{math.Round(side * c[0]), math.Round(side * c[1])}
+}
@@ -0,0 +1,244 @@
+// Package lns implements large neighborhood search for TSPPD. Each
+// iteration keeps most of the incumbent route in its original order, frees
+// a window or a random set of pickup and delivery pairs, and re-optimizes
+// them with a node-limited decision diagram search.
+package lns
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/construct"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/sequential"
+)
+
+// Search runs LNS over the sequential formulation.
+type Search struct {
+	Batch   int
+	Workers int
+
+	Pairs     int    // Pickup and delivery pairs to free in each neighborhood
+	Window    bool   // Free a window of consecutive nodes instead of random pairs
+	MaxNodes  uint64 // Node limit for each sub-problem
+	MaxMillis uint64 // Time limit for the whole search
+
+	// Improver is passed to the solver for each sub-problem.
+	Improver func(ddo.State) ddo.State
+
+	root        *sequential.State
+	rand        *rand.Rand
+	logger      ddo.Logger
+	logged      int64 // Cost of the last solution passed to logger
+	wallStart   time.Time
+	cpu         float64
+	fails       uint64
+	nodes       uint64
+	dual        int64 // Dual bound from search of the full problem
+	termination ddo.Termination
+
+	mutex   sync.Mutex
+	solver  *ddo.Solver // Solver for the current sub-problem
+	stopped int32       // Set atomically by Stop
+}
+
+// CreateSearch constructs an LNS from a sequential root state. The seed
+// drives the choice of neighborhoods.
+func CreateSearch(root *sequential.State, seed int64, logger ddo.Logger) *Search {
+	return &Search{
+		Batch:   1,
+		Workers: 1,
+		Pairs:   4,
+
+		root:   root,
+		rand:   rand.New(rand.NewSource(seed)),
+		logger: logger,
+	}
+}
+
+// Run searches from an optional incumbent until the time limit and returns
+// the best solution found. The first iteration is a node-limited search of
+// the full problem, which may find an initial incumbent or even prove
+// optimality. If it doesn't find one, regret insertion constructs one. If
+// that fails too, search ends at the node limit, since searching the full
+// problem again would explore the same nodes.
+func (s *Search) Run(incumbent ddo.State) ddo.State {
+	s.wallStart = time.Now()
+	s.logged = math.MaxInt64
+	if incumbent != nil {
+		s.logged = incumbent.Cost()
+	}
+
+	incumbent, optimal := s.solve(s.root, incumbent, true)
+	if incumbent == nil && !optimal && !s.stop() {
+		if solution := construct.RegretInsertion(s.root.Solution().Problem); solution != nil {
+			incumbent = sequential.CreateSolutionState(s.root, solution)
+		}
+	}
+
+	for incumbent != nil && !optimal && !s.stop() {
+		solution := incumbent.(tsppd.State).Solution()
+		root := sequential.CreateFixedOrderState(s.root, solution, s.neighborhood(solution))
+		incumbent, _ = s.solve(root, incumbent, false)
+	}
+
+	if optimal {
+		s.termination = ddo.Optimal
+	} else if incumbent == nil && !s.stop() {
+		s.termination = ddo.NodeLimit
+	} else if atomic.LoadInt32(&s.stopped) != 0 {
+		s.termination = ddo.Interrupted
+	} else {
+		s.termination = ddo.TimeLimit
+	}
+	return incumbent
+}
+
+// Stop ends search early. It is safe to call from another goroutine, such
+// as a signal handler.
+func (s *Search) Stop() {
+	atomic.StoreInt32(&s.stopped, 1)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.solver != nil {
+		s.solver.Stop()
+	}
+}
+
+// Statistics returns execution information for the search so far. The
+// dual bound comes from search of the full problem, since sub-problems
+// don't provide valid bounds.
+func (s *Search) Statistics() ddo.Statistics {
+	return ddo.Statistics{
+		ClockSeconds: s.elapsedSeconds(),
+		CPUSeconds:   s.cpu,
+		Optimal:      s.termination == ddo.Optimal,
+		Fails:        s.fails,
+		Nodes:        s.nodes,
+		DualBound:    s.dual,
+		Termination:  s.termination,
+	}
+}
+
+// solve runs a node-limited search of the full problem or a sub-problem.
+// Solutions from a sub-problem are logged only if they improve on the last
+// one logged, since a sub-problem reports its best solution again when it
+// is proven optimal. Only search of the full problem can prove optimality.
+func (s *Search) solve(root ddo.State, incumbent ddo.State, full bool) (ddo.State, bool) {
+	logger := func(b *ddo.Bounds, stats ddo.Statistics) {
+		if !full && b.PrimalBound() >= s.logged {
+			return
+		}
+		if b.Primal != nil {
+			s.logged = b.PrimalBound()
+		}
+
+		dual, termination := s.dual, ddo.Running
+		if full {
+			termination = stats.Termination
+			if stats.DualBound > dual {
+				dual = stats.DualBound
+			}
+		}
+		s.logger(b, ddo.Statistics{
+			ClockSeconds: s.elapsedSeconds(),
+			CPUSeconds:   s.cpu + stats.CPUSeconds,
+			Optimal:      full && stats.Optimal,
+			Fails:        s.fails + stats.Fails,
+			Nodes:        s.nodes + stats.Nodes,
+			DualBound:    dual,
+			Termination:  termination,
+		})
+	}
+
+	solver := ddo.CreateSolver(root, logger)
+	solver.Batch = s.Batch
+	solver.Workers = s.Workers
+	solver.MaxNodes = s.MaxNodes
+	solver.MaxMillis = s.remainingMillis()
+	solver.Improver = s.Improver
+
+	s.mutex.Lock()
+	s.solver = solver
+	s.mutex.Unlock()
+	if s.stop() {
+		solver.Stop()
+	}
+
+	if incumbent != nil {
+		solver.SetIncumbent(incumbent)
+	}
+	result := solver.Minimize()
+
+	stats := solver.Statistics()
+	s.cpu += stats.CPUSeconds
+	s.fails += stats.Fails
+	s.nodes += stats.Nodes
+	if full && stats.DualBound > s.dual {
+		s.dual = stats.DualBound
+	}
+
+	if result == nil {
+		return incumbent, false
+	}
+	return result, full && stats.Optimal
+}
+
+// neighborhood chooses the nodes to free from a solution.
+func (s *Search) neighborhood(solution *tsppd.Solution) *bitset.Set {
+	problem := solution.Problem
+	free := bitset.New(len(problem.Nodes))
+
+	// The start and end nodes are never free.
+	interior := solution.Path[1 : len(solution.Path)-1]
+	size := 2 * s.Pairs
+	if size > len(interior) {
+		size = len(interior)
+	}
+
+	if s.Window {
+		offset := s.rand.Intn(len(interior) - size + 1)
+		for _, node := range interior[offset : offset+size] {
+			index, _ := problem.Index(node)
+			free.Add(index)
+		}
+		return free
+	}
+
+	pickups := []int{}
+	for index := range problem.Nodes {
+		if problem.IsPickupIndex(index) {
+			pickups = append(pickups, index)
+		}
+	}
+	for _, k := range s.rand.Perm(len(pickups))[:size/2] {
+		free.Add(pickups[k])
+		free.Add(problem.PairIndex(pickups[k]))
+	}
+	return free
+}
+
+func (s *Search) stop() bool {
+	return atomic.LoadInt32(&s.stopped) != 0 || s.remainingMillis() == 0
+}
+
+// remainingMillis returns the time left for search, rounded up so that it
+// is only 0 once the time limit has passed.
+func (s *Search) remainingMillis() uint64 {
+	elapsed := time.Since(s.wallStart)
+	limit := time.Duration(s.MaxMillis) * time.Millisecond
+	if elapsed >= limit {
+		return 0
+	}
+	return uint64((limit - elapsed + time.Millisecond - 1) / time.Millisecond)
+}
+
+func (s *Search) elapsedSeconds() float64 {
+	return time.Since(s.wallStart).Seconds()
+}
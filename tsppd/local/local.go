@@ -0,0 +1,246 @@
+// Package local improves TSPPD solutions with precedence-aware local
+// search. Moves are 2-opt (segment reversal), or-opt (segment relocation)
+// and pair relocation (moving a pickup and its delivery together). Only
+// moves that keep each pickup before its delivery are accepted.
+package local
+
+import "github.com/ryanjoneil/tsppd-dd/tsppd"
+
+// maxSegment is the longest segment relocated by or-opt moves.
+const maxSegment = 3
+
+type search struct {
+	problem *tsppd.Problem
+	path    []int // Node indices, starting at the start and ending at the end
+	pos     []int // pos[i] = position of node index i in path
+	cost    int64
+}
+
+// Improve runs local search on a complete solution until no move improves
+// it. It returns the improved solution, and false if no improvement was
+// found. Incomplete or infeasible solutions are returned unchanged.
+func Improve(solution *tsppd.Solution) (*tsppd.Solution, bool) {
+	s := createSearch(solution)
+	if s == nil {
+		return solution, false
+	}
+
+	original := s.cost
+	for s.twoOpt() || s.orOpt() || s.relocatePair() {
+	}
+	if s.cost >= original {
+		return solution, false
+	}
+
+	path := make([]string, len(s.path))
+	for i, index := range s.path {
+		path[i] = s.problem.Nodes[index]
+	}
+	return &tsppd.Solution{Problem: solution.Problem, Path: path}, true
+}
+
+func createSearch(solution *tsppd.Solution) *search {
+	problem := solution.Problem
+	if len(solution.Path) != len(problem.Nodes) {
+		return nil
+	}
+
+	path := make([]int, len(solution.Path))
+	for i, node := range solution.Path {
+		index, ok := problem.Index(node)
+		if !ok {
+			return nil
+		}
+		path[i] = index
+	}
+
+	s := &search{problem: problem, pos: make([]int, len(path))}
+	if !s.isFeasible(path) {
+		return nil
+	}
+	s.apply(path)
+	s.cost = s.pathCost(path)
+	return s
+}
+
+// twoOpt reverses the first improving segment path[i..j] it finds.
+func (s *search) twoOpt() bool {
+	n := len(s.path)
+	for i := 1; i < n-2; i++ {
+		var forward, reverse int64
+		for j := i + 1; j < n-1; j++ {
+			// Any segment containing both nodes of a pair can't be reversed.
+			if s.problem.IsDeliveryIndex(s.path[j]) && s.pos[s.problem.PairIndex(s.path[j])] >= i {
+				break
+			}
+
+			forward += s.c(s.path[j-1], s.path[j])
+			reverse += s.c(s.path[j], s.path[j-1])
+
+			delta := s.c(s.path[i-1], s.path[j]) + reverse + s.c(s.path[i], s.path[j+1]) -
+				s.c(s.path[i-1], s.path[i]) - forward - s.c(s.path[j], s.path[j+1])
+			if delta >= 0 {
+				continue
+			}
+
+			path := make([]int, 0, n)
+			path = append(path, s.path[:i]...)
+			for k := j; k >= i; k-- {
+				path = append(path, s.path[k])
+			}
+			path = append(path, s.path[j+1:]...)
+			if s.accept(path, delta) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orOpt moves the first improving segment of up to maxSegment nodes it
+// finds to another position in the path.
+func (s *search) orOpt() bool {
+	n := len(s.path)
+	for length := 1; length <= maxSegment; length++ {
+		for i := 1; i+length < n; i++ {
+			first, last := s.path[i], s.path[i+length-1]
+			before, after := s.path[i-1], s.path[i+length]
+			removal := s.c(before, after) - s.c(before, first) - s.c(last, after)
+
+			for k := 0; k < n-1; k++ {
+				if k >= i-1 && k < i+length {
+					continue
+				}
+
+				a, b := s.path[k], s.path[k+1]
+				delta := removal + s.c(a, first) + s.c(last, b) - s.c(a, b)
+				if delta >= 0 {
+					continue
+				}
+
+				segment := s.path[i : i+length]
+				path := make([]int, 0, n)
+				if k < i {
+					path = append(path, s.path[:k+1]...)
+					path = append(path, segment...)
+					path = append(path, s.path[k+1:i]...)
+					path = append(path, s.path[i+length:]...)
+				} else {
+					path = append(path, s.path[:i]...)
+					path = append(path, s.path[i+length:k+1]...)
+					path = append(path, segment...)
+					path = append(path, s.path[k+1:]...)
+				}
+				if s.accept(path, delta) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// relocatePair removes a pickup and its delivery from the path and
+// reinserts them at the first improving pair of positions it finds.
+func (s *search) relocatePair() bool {
+	n := len(s.path)
+	for _, pickup := range s.path {
+		if !s.problem.IsPickupIndex(pickup) {
+			continue
+		}
+		delivery := s.problem.PairIndex(pickup)
+
+		rest := make([]int, 0, n-2)
+		for _, index := range s.path {
+			if index != pickup && index != delivery {
+				rest = append(rest, index)
+			}
+		}
+		removal := s.pathCost(rest) - s.cost
+
+		for a := 0; a < len(rest)-1; a++ {
+			insertPickup := s.c(rest[a], pickup) + s.c(pickup, rest[a+1]) - s.c(rest[a], rest[a+1])
+
+			for b := a; b < len(rest)-1; b++ {
+				var delta int64
+				if a == b {
+					delta = removal + s.c(rest[a], pickup) + s.c(pickup, delivery) +
+						s.c(delivery, rest[a+1]) - s.c(rest[a], rest[a+1])
+				} else {
+					delta = removal + insertPickup + s.c(rest[b], delivery) +
+						s.c(delivery, rest[b+1]) - s.c(rest[b], rest[b+1])
+				}
+				if delta >= 0 {
+					continue
+				}
+
+				path := make([]int, 0, n)
+				path = append(path, rest[:a+1]...)
+				path = append(path, pickup)
+				if a < b {
+					path = append(path, rest[a+1:b+1]...)
+				}
+				path = append(path, delivery)
+				path = append(path, rest[b+1:]...)
+				if s.accept(path, delta) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// accept replaces the current path if a candidate is feasible.
+func (s *search) accept(path []int, delta int64) bool {
+	if !s.isFeasible(path) {
+		return false
+	}
+	s.apply(path)
+	s.cost += delta
+	return true
+}
+
+func (s *search) apply(path []int) {
+	s.path = path
+	for i, index := range path {
+		s.pos[index] = i
+	}
+}
+
+// isFeasible returns true if a path starts at the start, ends at the end,
+// uses only feasible arcs, and visits each pickup before its delivery.
+func (s *search) isFeasible(path []int) bool {
+	n := len(path)
+	if path[0] != s.problem.StartIndex() || path[n-1] != s.problem.EndIndex() {
+		return false
+	}
+
+	visited := make([]bool, n)
+	for i, index := range path {
+		if visited[index] {
+			return false
+		}
+		visited[index] = true
+
+		if s.problem.IsDeliveryIndex(index) && !visited[s.problem.PairIndex(index)] {
+			return false
+		}
+		if i > 0 && !s.problem.IsFeasibleIndex(path[i-1], index) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *search) pathCost(path []int) int64 {
+	var cost int64
+	for i := 1; i < len(path); i++ {
+		cost += s.c(path[i-1], path[i])
+	}
+	return cost
+}
+
+func (s *search) c(index1, index2 int) int64 {
+	return s.problem.CostIndex(index1, index2)
+}
@@ -0,0 +1,62 @@
+package tsppd
+
+import (
+	"fmt"
+	"math"
+)
+
+const earthRadius = 6371000.0 // meters
+
+// Metric computes the cost of travelling between two coordinates.
+type Metric func(c1, c2 []float64) int64
+
+var metrics = map[string]Metric{
+	"euclidean":      Euclidean,
+	"ceil-euclidean": CeilEuclidean,
+	"manhattan":      Manhattan,
+	"haversine":      Haversine,
+}
+
+// LookupMetric returns a Metric by name.
+func LookupMetric(name string) (Metric, error) {
+	metric, ok := metrics[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", name)
+	}
+	return metric, nil
+}
+
+// Euclidean distance rounded to the nearest integer, as in TSPLIB's EUC_2D.
+func Euclidean(c1, c2 []float64) int64 {
+	return nint(math.Hypot(c1[0]-c2[0], c1[1]-c2[1]))
+}
+
+// CeilEuclidean distance rounded up to the next integer, as in TSPLIB's CEIL_2D.
+func CeilEuclidean(c1, c2 []float64) int64 {
+	return int64(math.Ceil(math.Hypot(c1[0]-c2[0], c1[1]-c2[1])))
+}
+
+// Manhattan distance rounded to the nearest integer, as in TSPLIB's MAN_2D.
+func Manhattan(c1, c2 []float64) int64 {
+	return nint(math.Abs(c1[0]-c2[0]) + math.Abs(c1[1]-c2[1]))
+}
+
+// Haversine distance in meters between two (latitude, longitude) pairs
+// given in degrees, rounded to the nearest integer.
+func Haversine(c1, c2 []float64) int64 {
+	lat1, lon1 := radians(c1[0]), radians(c1[1])
+	lat2, lon2 := radians(c2[0]), radians(c2[1])
+
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((lon2 - lon1) / 2)
+	a := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+	return nint(2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a))))
+}
+
+func nint(x float64) int64 {
+	return int64(x + 0.5)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,123 @@
+package preprocess
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+)
+
+// Preprocessor eliminates arcs from a copy of a TSPPD instance before and
+// during search, leaving the original instance unchanged. Arcs that can't
+// be in any feasible path are removed based on the transitive closure of
+// precedence. Once an incumbent exists, arcs that can't be in any better
+// path are removed based on the reduced costs of the root AP relaxation.
+type Preprocessor struct {
+	Precedence  int // Arcs eliminated by precedence
+	ReducedCost int // Arcs eliminated by reduced cost
+
+	problem *tsppd.Problem
+	ap      *apdual.State
+	before  []*bitset.Set // before[i] = nodes that must precede i
+}
+
+// Preprocess copies a problem, removes arcs from the copy that are
+// infeasible by precedence, and solves the root AP for reduced cost fixing.
+// Search should use the copy, which is returned by Problem.
+func Preprocess(problem *tsppd.Problem) *Preprocessor {
+	p := &Preprocessor{problem: problem.Copy()}
+	p.initPrecedence()
+	p.removePrecedence()
+	p.ap = apdual.CreateAPDualState(p.problem)
+	return p
+}
+
+// Problem returns the copy of the problem that arcs are removed from.
+func (p *Preprocessor) Problem() *tsppd.Problem {
+	return p.problem
+}
+
+// Fix removes arcs with AP reduced costs that would make any path using
+// them cost at least as much as the incumbent. It should be called each
+// time the incumbent improves, and returns the number of arcs removed.
+func (p *Preprocessor) Fix(incumbent int64) int {
+	removed := 0
+	for index1 := range p.problem.Nodes {
+		for index2 := range p.problem.Nodes {
+			if !p.problem.IsFeasibleIndex(index1, index2) {
+				continue
+			}
+			if p.ap.Cost()+p.ap.RC(index1, index2) >= incumbent && p.problem.RemoveArc(index1, index2) {
+				removed++
+			}
+		}
+	}
+
+	p.ReducedCost += removed
+	return removed
+}
+
+// Eliminated returns the total number of arcs removed.
+func (p *Preprocessor) Eliminated() int {
+	return p.Precedence + p.ReducedCost
+}
+
+// initPrecedence computes the transitive closure of precedence: the start
+// precedes every node, every node precedes the end, and each pickup
+// precedes its delivery.
+func (p *Preprocessor) initPrecedence() {
+	n := len(p.problem.Nodes)
+	start, end := p.problem.StartIndex(), p.problem.EndIndex()
+
+	p.before = make([]*bitset.Set, n)
+	for index := range p.before {
+		p.before[index] = bitset.New(n)
+		if index != start {
+			p.before[index].Add(start)
+		}
+		if p.problem.IsDeliveryIndex(index) {
+			p.before[index].Add(p.problem.PairIndex(index))
+		}
+	}
+	for index := range p.before {
+		if index != end {
+			p.before[end].Add(index)
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for index := 0; index < n; index++ {
+			if p.before[index].Contains(k) {
+				p.before[index].AddAll(p.before[k])
+			}
+		}
+	}
+}
+
+// removePrecedence removes each arc (i j) where j must precede i, or where
+// some other node must come after i and before j.
+func (p *Preprocessor) removePrecedence() {
+	n := len(p.problem.Nodes)
+
+	after := make([]*bitset.Set, n) // after[i] = nodes that must follow i
+	for index := range after {
+		after[index] = bitset.New(n)
+	}
+	for index2, before := range p.before {
+		for index1 := before.Min(); index1 >= 0; index1 = before.Next(index1 + 1) {
+			after[index1].Add(index2)
+		}
+	}
+
+	for index1 := 0; index1 < n; index1++ {
+		for index2 := 0; index2 < n; index2++ {
+			if !p.problem.IsFeasibleIndex(index1, index2) {
+				continue
+			}
+			if p.before[index1].Contains(index2) || after[index1].Intersects(p.before[index2]) {
+				if p.problem.RemoveArc(index1, index2) {
+					p.Precedence++
+				}
+			}
+		}
+	}
+}
@@ -2,21 +2,58 @@ package tsppd
 
 import (
 	"encoding/json"
-	"math"
-	"strings"
+	"fmt"
+	"sync/atomic"
 )
 
-// Problem represents a TSPPD instance.
+// Default names of the start and end nodes.
+const (
+	DefaultStart = "+0"
+	DefaultEnd   = "-0"
+)
+
+// Problem represents a TSPPD instance. Arc costs are given either as a
+// full Edges matrix or as node Coordinates along with a Metric name, in
+// which case the Edges matrix is computed when the Problem is decoded.
+//
+// Node names are arbitrary. Start and End name the depot nodes, and
+// default to +0 and -0. Every other node is a pickup or a delivery, as
+// given by the pickup -> delivery pairs in Precedence.
 type Problem struct {
-	Name       string
-	Comment    string
-	Nodes      []string
-	Precedence map[string]string
-	Edges      [][]int64
+	Name        string
+	Comment     string `json:",omitempty"`
+	Nodes       []string
+	Start       string `json:",omitempty"`
+	End         string `json:",omitempty"`
+	Precedence  map[string]string
+	Edges       [][]int64            `json:",omitempty"`
+	Coordinates map[string][]float64 `json:",omitempty"`
+	Metric      string               `json:",omitempty"`
 
 	index map[string]int
+	roles []role // roles[i] = role of node i
+	pairs []int  // pairs[i] = delivery of pickup i, or pickup of delivery i
+	start int
+	end   int
+
+	// removed is a bit matrix of arcs eliminated by RemoveArc. It is
+	// accessed atomically so arcs can be removed during search.
+	removed []uint64
+	words   int
+
+	computed bool // Edges were computed from Coordinates
 }
 
+type role uint8
+
+const (
+	noRole role = iota
+	startRole
+	endRole
+	pickupRole
+	deliveryRole
+)
+
 // Decode converts a JSON byte array into a TSPPD Problem instance.
 func Decode(b []byte) (Problem, error) {
 	var p Problem
@@ -24,10 +61,39 @@ func Decode(b []byte) (Problem, error) {
 		return Problem{}, err
 	}
 
-	p.init()
+	if err := p.init(); err != nil {
+		return Problem{}, err
+	}
 	return p, nil
 }
 
+// CreateProblem initializes a Problem built in code, such as a generated
+// instance, the same way Decode does for one read from JSON.
+func CreateProblem(p Problem) (*Problem, error) {
+	if err := p.init(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// MarshalJSON encodes a Problem as it would be given. Edges computed from
+// Coordinates, default Start and End nodes, and an empty Comment are left
+// out.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type fields Problem // Drops MarshalJSON to avoid recursion
+	f := fields(p)
+	if f.computed {
+		f.Edges = nil
+	}
+	if f.Start == DefaultStart {
+		f.Start = ""
+	}
+	if f.End == DefaultEnd {
+		f.End = ""
+	}
+	return json.Marshal(f)
+}
+
 // IsEmpty is true if a Problem has no data.
 func (p *Problem) IsEmpty() bool {
 	return len(p.Nodes) == 0 && len(p.Precedence) == 0 && len(p.Edges) == 0
@@ -44,80 +110,278 @@ func (p *Problem) Precedes(node1, node2 string) bool {
 	return p.Precedence[node1] == node2
 }
 
-// IsStart returns true if a node is +0.
+// IsStart returns true if a node is the start node.
 func (p *Problem) IsStart(node string) bool {
-	return node == "+0"
+	return p.is(node, startRole)
 }
 
-// IsEnd returns true if a node is -0.
+// IsEnd returns true if a node is the end node.
 func (p *Problem) IsEnd(node string) bool {
-	return node == "-0"
+	return p.is(node, endRole)
 }
 
-// IsPickup returns true if a node is a pickup (i.e. contains +, not +0).
+// IsPickup returns true if a node is a pickup.
 func (p *Problem) IsPickup(node string) bool {
-	return strings.Contains(node, "+") && !p.IsStart(node)
+	return p.is(node, pickupRole)
 }
 
-// IsDelivery returns true if a node is a delivery (i.e. contains -, not -0).
+// IsDelivery returns true if a node is a delivery.
 func (p *Problem) IsDelivery(node string) bool {
-	return strings.Contains(node, "-") && !p.IsEnd(node)
+	return p.is(node, deliveryRole)
 }
 
 // IsFeasible returns true if a directed edge from node1 to node2 is feasible.
 func (p *Problem) IsFeasible(node1, node2 string) bool {
-	// +0 has no predecessor. -0 has no successor.
-	if p.IsStart(node2) || p.IsEnd(node1) {
+	index1, ok1 := p.Index(node1)
+	index2, ok2 := p.Index(node2)
+	return ok1 && ok2 && p.IsFeasibleIndex(index1, index2)
+}
+
+// FeasibleEdges returns the possible end nodes starting at a given node.
+func (p *Problem) FeasibleEdges(node string) []string {
+	edges := []string{}
+	for _, end := range p.Nodes {
+		if p.IsFeasible(node, end) {
+			edges = append(edges, end)
+		}
+	}
+	return edges
+}
+
+// Cost returns the cost of a directed arc from node1 to node2. It returns
+// false if either node is unknown or the arc has no cost in Edges.
+func (p *Problem) Cost(node1, node2 string) (int64, bool) {
+	row, okRow := p.Index(node1)
+	col, okCol := p.Index(node2)
+	if !okRow || !okCol || row >= len(p.Edges) || col >= len(p.Edges[row]) {
+		return 0, false
+	}
+	return p.Edges[row][col], true
+}
+
+// CostIndex returns the cost of a directed arc from the node at index1 to
+// the node at index2.
+func (p *Problem) CostIndex(index1, index2 int) int64 {
+	return p.Edges[index1][index2]
+}
+
+// InfeasibleCost returns a cost for arcs that can't be in a solution. It is
+// the sum of the largest arc cost leaving each node, plus one, so it is more
+// than any path or assignment costs using only real arcs. Validate ensures
+// that len(Nodes) arcs at this cost fit within MaxPathCost.
+func (p *Problem) InfeasibleCost() int64 {
+	var total int64
+	for row, edges := range p.Edges {
+		var max int64
+		for col, cost := range edges {
+			if row != col && cost > max {
+				max = cost
+			}
+		}
+		total += max
+	}
+	return total + 1
+}
+
+// StartIndex returns the index of the start node, or -1 if there is none.
+func (p *Problem) StartIndex() int {
+	return p.start
+}
+
+// EndIndex returns the index of the end node, or -1 if there is none.
+func (p *Problem) EndIndex() int {
+	return p.end
+}
+
+// IsPickupIndex returns true if the node at an index is a pickup.
+func (p *Problem) IsPickupIndex(index int) bool {
+	return p.roles[index] == pickupRole
+}
+
+// IsDeliveryIndex returns true if the node at an index is a delivery.
+func (p *Problem) IsDeliveryIndex(index int) bool {
+	return p.roles[index] == deliveryRole
+}
+
+// PairIndex returns the index of the delivery for a pickup, or of the
+// pickup for a delivery. It returns -1 for the start and end nodes.
+func (p *Problem) PairIndex(index int) int {
+	return p.pairs[index]
+}
+
+// PrecedesIndex returns true if the node at index1 must come before the
+// node at index2, i.e. they are a pickup and its delivery. Other nodes may
+// be visited between them.
+func (p *Problem) PrecedesIndex(index1, index2 int) bool {
+	return p.roles[index1] == pickupRole && p.pairs[index1] == index2
+}
+
+// IsFeasibleIndex returns true if a directed edge from the node at index1
+// to the node at index2 is feasible.
+func (p *Problem) IsFeasibleIndex(index1, index2 int) bool {
+	// The start has no predecessor. The end has no successor.
+	if index2 == p.start || index1 == p.end {
 		return false
 	}
 
 	// Nodes can't connect to themselves.
-	if node1 == node2 {
+	if index1 == index2 {
 		return false
 	}
 
 	// Precedence relations can't be violated.
-	if p.Precedes(node2, node1) {
+	if p.PrecedesIndex(index2, index1) {
 		return false
 	}
 
-	// +0 can't connect to a delivery or directly to the end node.
-	if p.IsStart(node1) && (p.IsDelivery(node2) || p.IsEnd(node2)) {
+	// The start can't connect to a delivery, or directly to the end node
+	// unless there are no other nodes.
+	if index1 == p.start && (p.roles[index2] == deliveryRole || index2 == p.end && len(p.Nodes) > 2) {
 		return false
 	}
 
-	// Pickups can't connect to -0.
-	if p.IsPickup(node1) && p.IsEnd(node2) {
+	// Pickups can't connect to the end.
+	if p.roles[index1] == pickupRole && index2 == p.end {
 		return false
 	}
 
+	// Arcs may have been eliminated by preprocessing.
+	return !p.IsRemovedArc(index1, index2)
+}
+
+// RemoveArc eliminates the arc from the node at index1 to the node at
+// index2, so it is no longer feasible. It returns false if the arc was
+// already removed. RemoveArc is safe to call while other goroutines check
+// arc feasibility, but not concurrently with itself.
+func (p *Problem) RemoveArc(index1, index2 int) bool {
+	if p.removed == nil {
+		p.initRemoved()
+	}
+
+	word := &p.removed[index1*p.words+index2/64]
+	bit := uint64(1) << uint(index2%64)
+	old := atomic.LoadUint64(word)
+	if old&bit != 0 {
+		return false
+	}
+	atomic.StoreUint64(word, old|bit)
 	return true
 }
 
-// FeasibleEdges returns the possible end nodes starting at a given node.
-func (p *Problem) FeasibleEdges(node string) []string {
-	edges := []string{}
-	for _, end := range p.Nodes {
-		if p.IsFeasible(node, end) {
-			edges = append(edges, end)
+// Copy returns a copy of a problem with its own set of removed arcs, so
+// arcs can be removed from one without changing the other. Everything else
+// is shared, and shouldn't be modified.
+func (p *Problem) Copy() *Problem {
+	c := *p
+	if p.removed != nil {
+		c.removed = make([]uint64, len(p.removed))
+		for i := range p.removed {
+			c.removed[i] = atomic.LoadUint64(&p.removed[i])
 		}
 	}
-	return edges
+	return &c
 }
 
-// Cost returns the cost of a directed arc from node1 to node2.
-func (p *Problem) Cost(node1, node2 string) (int64, bool) {
-	row, okRow := p.Index(node1)
-	col, okCol := p.Index(node2)
-	if !okRow || !okCol {
-		return math.MaxInt16, false
+// IsRemovedArc returns true if an arc has been eliminated by RemoveArc.
+func (p *Problem) IsRemovedArc(index1, index2 int) bool {
+	if p.removed == nil {
+		return false
 	}
-	return p.Edges[row][col], true
+	word := atomic.LoadUint64(&p.removed[index1*p.words+index2/64])
+	return word&(uint64(1)<<uint(index2%64)) != 0
+}
+
+func (p *Problem) is(node string, r role) bool {
+	index, ok := p.Index(node)
+	return ok && p.roles[index] == r
 }
 
-func (p *Problem) init() {
+func (p *Problem) init() error {
+	if p.Start == "" {
+		p.Start = DefaultStart
+	}
+	if p.End == "" {
+		p.End = DefaultEnd
+	}
+
 	p.index = map[string]int{}
 	for index, node := range p.Nodes {
 		p.index[node] = index
 	}
+
+	p.initRoles()
+	p.initRemoved()
+
+	if len(p.Edges) == 0 && len(p.Coordinates) > 0 {
+		p.computed = true
+		return p.initEdges()
+	}
+	return nil
+}
+
+// initRoles caches the role of each node and the index of its pair.
+func (p *Problem) initRoles() {
+	p.roles = make([]role, len(p.Nodes))
+	p.pairs = make([]int, len(p.Nodes))
+	for index := range p.pairs {
+		p.pairs[index] = -1
+	}
+
+	for pickup, delivery := range p.Precedence {
+		index1, ok1 := p.Index(pickup)
+		index2, ok2 := p.Index(delivery)
+		if ok1 && ok2 {
+			p.roles[index1], p.pairs[index1] = pickupRole, index2
+			p.roles[index2], p.pairs[index2] = deliveryRole, index1
+		}
+	}
+
+	p.start, p.end = -1, -1
+	if index, ok := p.Index(p.Start); ok {
+		p.start = index
+		p.roles[index], p.pairs[index] = startRole, -1
+	}
+	if index, ok := p.Index(p.End); ok {
+		p.end = index
+		p.roles[index], p.pairs[index] = endRole, -1
+	}
+}
+
+func (p *Problem) initRemoved() {
+	p.words = (len(p.Nodes) + 63) / 64
+	p.removed = make([]uint64, len(p.Nodes)*p.words)
+}
+
+// initEdges computes the Edges matrix from node coordinates.
+func (p *Problem) initEdges() error {
+	if p.Metric == "" {
+		return fmt.Errorf("coordinates require a metric")
+	}
+	metric, err := LookupMetric(p.Metric)
+	if err != nil {
+		return err
+	}
+
+	coords := make([][]float64, len(p.Nodes))
+	for index, node := range p.Nodes {
+		c, ok := p.Coordinates[node]
+		if !ok {
+			return fmt.Errorf("node %s has no coordinates", node)
+		}
+		if len(c) != 2 {
+			return fmt.Errorf("node %s must have 2 coordinates, has %d", node, len(c))
+		}
+		coords[index] = c
+	}
+
+	p.Edges = make([][]int64, len(p.Nodes))
+	for index1 := range p.Nodes {
+		p.Edges[index1] = make([]int64, len(p.Nodes))
+		for index2 := range p.Nodes {
+			if index1 != index2 {
+				p.Edges[index1][index2] = metric(coords[index1], coords[index2])
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,114 @@
+// Package reference solves tiny TSPPD instances exactly with dynamic
+// programming, to check the results of other solvers.
+package reference
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// MaxNodes is the most pickup and delivery nodes Solve accepts. Memory
+// use grows with 2^MaxNodes.
+const MaxNodes = 16
+
+// Solve returns an optimal solution to a problem, or nil if the problem is
+// infeasible. It is a Held-Karp style dynamic program over the set of
+// pickups and deliveries visited and the last node visited, which only
+// allows a delivery once its pickup is in the set.
+func Solve(problem *tsppd.Problem) (*tsppd.Solution, error) {
+	start, end := problem.StartIndex(), problem.EndIndex()
+	if start < 0 || end < 0 {
+		return nil, fmt.Errorf("problem requires start and end nodes")
+	}
+
+	// bit[i] = position of node i in the visited set, or -1 for the depot.
+	bit := make([]int, len(problem.Nodes))
+	nodes := []int{}
+	for index := range problem.Nodes {
+		bit[index] = -1
+		if index != start && index != end {
+			bit[index] = len(nodes)
+			nodes = append(nodes, index)
+		}
+	}
+	if len(nodes) > MaxNodes {
+		return nil, fmt.Errorf("problem has %d nodes, at most %d are supported", len(nodes), MaxNodes)
+	}
+
+	// cost[set][i] = min cost of a path from the start through set that
+	// ends at nodes[i], and last[set][i] = the node before it.
+	sets := 1 << uint(len(nodes))
+	cost := make([][]int64, sets)
+	last := make([][]int, sets)
+	for set := range cost {
+		cost[set] = make([]int64, len(nodes))
+		last[set] = make([]int, len(nodes))
+		for i := range cost[set] {
+			cost[set][i] = math.MaxInt64
+		}
+	}
+
+	allows := func(set, index int) bool {
+		return !problem.IsDeliveryIndex(index) || set&(1<<uint(bit[problem.PairIndex(index)])) != 0
+	}
+
+	for i, index := range nodes {
+		if problem.IsFeasibleIndex(start, index) && allows(0, index) {
+			cost[1<<uint(i)][i] = problem.CostIndex(start, index)
+			last[1<<uint(i)][i] = start
+		}
+	}
+
+	for set := 1; set < sets; set++ {
+		for i, index1 := range nodes {
+			if cost[set][i] == math.MaxInt64 {
+				continue
+			}
+			for j, index2 := range nodes {
+				next := set | 1<<uint(j)
+				if next == set || !problem.IsFeasibleIndex(index1, index2) || !allows(set, index2) {
+					continue
+				}
+				if c := cost[set][i] + problem.CostIndex(index1, index2); c < cost[next][j] {
+					cost[next][j] = c
+					last[next][j] = index1
+				}
+			}
+		}
+	}
+
+	// Close the path at the end node.
+	full := sets - 1
+	best, bestLast := int64(math.MaxInt64), -1
+	if len(nodes) == 0 && problem.IsFeasibleIndex(start, end) {
+		best, bestLast = problem.CostIndex(start, end), start
+	}
+	for i, index := range nodes {
+		if cost[full][i] == math.MaxInt64 || !problem.IsFeasibleIndex(index, end) {
+			continue
+		}
+		if c := cost[full][i] + problem.CostIndex(index, end); c < best {
+			best, bestLast = c, index
+		}
+	}
+	if bestLast < 0 {
+		return nil, nil
+	}
+
+	// Walk back from the end to recover the path.
+	path := []string{problem.Nodes[end]}
+	for set, index := full, bestLast; index != start; {
+		path = append(path, problem.Nodes[index])
+		previous := last[set][bit[index]]
+		set &^= 1 << uint(bit[index])
+		index = previous
+	}
+	path = append(path, problem.Nodes[start])
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return &tsppd.Solution{Problem: problem, Path: path}, nil
+}
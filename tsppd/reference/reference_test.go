@@ -0,0 +1,90 @@
+package reference_test
+
+import (
+	"testing"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/generate"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/reference"
+)
+
+func TestSolveMatchesEnumeration(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		problem, err := generate.Generate(generate.Options{
+			Pairs:        1 + int(seed%4),
+			Distribution: "uniform",
+			Metric:       "euclidean",
+			Asymmetry:    0.5,
+			Seed:         seed,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		solution, err := reference.Solve(problem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := solution.Validate(); err != nil {
+			t.Fatalf("seed %d: invalid solution %v: %v", seed, solution.Path, err)
+		}
+
+		cost, _ := solution.Cost()
+		if expected := enumerate(problem); cost != expected {
+			t.Errorf("seed %d: cost %d, expected %d", seed, cost, expected)
+		}
+	}
+}
+
+func TestSolveInfeasible(t *testing.T) {
+	problem, err := generate.Generate(generate.Options{
+		Pairs:        2,
+		Distribution: "uniform",
+		Metric:       "euclidean",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index := range problem.Nodes {
+		problem.RemoveArc(problem.StartIndex(), index)
+	}
+
+	solution, err := reference.Solve(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution != nil {
+		t.Errorf("expected no solution, got %v", solution.Path)
+	}
+}
+
+// enumerate returns the cost of the cheapest valid permutation of nodes.
+func enumerate(problem *tsppd.Problem) int64 {
+	best := int64(-1)
+	path := []string{problem.Nodes[problem.StartIndex()]}
+	used := map[string]bool{path[0]: true, problem.Nodes[problem.EndIndex()]: true}
+
+	var extend func()
+	extend = func() {
+		if len(path) == len(problem.Nodes)-1 {
+			solution := &tsppd.Solution{Problem: problem, Path: append(path, problem.Nodes[problem.EndIndex()])}
+			if solution.Validate() == nil {
+				if cost, ok := solution.Cost(); ok && (best < 0 || cost < best) {
+					best = cost
+				}
+			}
+			return
+		}
+		for _, node := range problem.Nodes {
+			if !used[node] {
+				used[node] = true
+				path = append(path, node)
+				extend()
+				path = path[:len(path)-1]
+				used[node] = false
+			}
+		}
+	}
+	extend()
+	return best
+}
@@ -0,0 +1,199 @@
+// Package registry holds the formulations, inference duals, relaxations,
+// and orderings available to solvers. Components register themselves by
+// name when their packages are initialized, so other packages can add
+// components by registering them in the same way.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// Component names and describes an option of a formulation.
+type Component struct {
+	Name        string
+	Description string
+}
+
+// Ordering is a decision ordering for a formulation. Create returns a
+// value of the type the formulation documents for its orderings.
+type Ordering struct {
+	Component
+	RequiresInference bool // The ordering uses an inference dual
+	Create            func(problem *tsppd.Problem) interface{}
+}
+
+// Relaxation is a relaxation dual for a formulation. Create returns the
+// mergers used to build relaxed decision diagrams.
+type Relaxation struct {
+	Component
+	Create func(problem *tsppd.Problem) []ddo.Merger
+}
+
+// Formulation builds decision diagrams for TSPPD instances.
+type Formulation struct {
+	Component
+	Relaxations      []Relaxation // Relaxation duals supported besides "none"
+	Orderings        []Ordering   // Decision orderings supported
+	OrderingOptional bool         // If true, no ordering may be given
+
+	// CreateRootState makes the root state for a problem.
+	CreateRootState func(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) ddo.State
+
+	// CreateSolutionState converts a solution into a state that extends
+	// a root state made by CreateRootState.
+	CreateSolutionState func(root ddo.State, solution *tsppd.Solution) ddo.State
+}
+
+// InferenceDual bounds states of any formulation.
+type InferenceDual struct {
+	Component
+	Create func(problem *tsppd.Problem) tsppd.InferenceDual
+}
+
+var (
+	mutex          sync.RWMutex
+	formulations   = map[string]Formulation{}
+	inferenceDuals = map[string]InferenceDual{}
+)
+
+// RegisterFormulation makes a formulation available by name. It panics if
+// the name is already registered.
+func RegisterFormulation(f Formulation) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := formulations[f.Name]; ok {
+		panic("registry: formulation " + f.Name + " registered twice")
+	}
+	formulations[f.Name] = f
+}
+
+// RegisterInferenceDual makes an inference dual available by name. It
+// panics if the name is already registered, or is reserved.
+func RegisterInferenceDual(d InferenceDual) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if d.Name == "none" {
+		panic("registry: inference dual name none is reserved")
+	}
+	if _, ok := inferenceDuals[d.Name]; ok {
+		panic("registry: inference dual " + d.Name + " registered twice")
+	}
+	inferenceDuals[d.Name] = d
+}
+
+// RegisterOrdering adds an ordering to a registered formulation. It panics
+// if the formulation is not registered or already has the ordering.
+func RegisterOrdering(formulation string, o Ordering) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	f, ok := formulations[formulation]
+	if !ok {
+		panic("registry: ordering " + o.Name + " registered for unknown formulation " + formulation)
+	}
+	if _, ok := f.Ordering(o.Name); ok {
+		panic("registry: ordering " + o.Name + " registered twice for " + formulation)
+	}
+	f.Orderings = append(f.Orderings[:len(f.Orderings):len(f.Orderings)], o)
+	formulations[formulation] = f
+}
+
+// RegisterRelaxation adds a relaxation dual to a registered formulation.
+// It panics if the formulation is not registered or already has the
+// relaxation, or if the name is reserved.
+func RegisterRelaxation(formulation string, r Relaxation) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if r.Name == "none" {
+		panic("registry: relaxation name none is reserved")
+	}
+	f, ok := formulations[formulation]
+	if !ok {
+		panic("registry: relaxation " + r.Name + " registered for unknown formulation " + formulation)
+	}
+	if _, ok := f.Relaxation(r.Name); ok {
+		panic("registry: relaxation " + r.Name + " registered twice for " + formulation)
+	}
+	f.Relaxations = append(f.Relaxations[:len(f.Relaxations):len(f.Relaxations)], r)
+	formulations[formulation] = f
+}
+
+// LookupFormulation returns a formulation by name.
+func LookupFormulation(name string) (Formulation, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	f, ok := formulations[name]
+	if !ok {
+		return Formulation{}, fmt.Errorf("unknown formulation %q", name)
+	}
+	return f, nil
+}
+
+// LookupInferenceDual returns an inference dual by name.
+func LookupInferenceDual(name string) (InferenceDual, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	d, ok := inferenceDuals[name]
+	if !ok {
+		return InferenceDual{}, fmt.Errorf("unknown inference dual %q", name)
+	}
+	return d, nil
+}
+
+// Formulations returns every registered formulation, sorted by name.
+func Formulations() []Formulation {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	all := make([]Formulation, 0, len(formulations))
+	for _, f := range formulations {
+		all = append(all, f)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// InferenceDuals returns every registered inference dual, sorted by name.
+func InferenceDuals() []InferenceDual {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	all := make([]InferenceDual, 0, len(inferenceDuals))
+	for _, d := range inferenceDuals {
+		all = append(all, d)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Ordering returns an ordering of a formulation by name.
+func (f Formulation) Ordering(name string) (Ordering, bool) {
+	for _, o := range f.Orderings {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Ordering{}, false
+}
+
+// Relaxation returns a relaxation dual of a formulation by name.
+func (f Formulation) Relaxation(name string) (Relaxation, bool) {
+	for _, r := range f.Relaxations {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Relaxation{}, false
+}
+
+// SupportsRelaxation returns true if a formulation supports a relaxation
+// dual. Every formulation supports "none".
+func (f Formulation) SupportsRelaxation(name string) bool {
+	if name == "none" {
+		return true
+	}
+	_, ok := f.Relaxation(name)
+	return ok
+}
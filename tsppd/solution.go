@@ -1,6 +1,11 @@
 package tsppd
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
 
 // Solution represents a TSPPD path.
 type Solution struct {
@@ -8,6 +13,18 @@ type Solution struct {
 	Path    []string
 }
 
+// ParsePath splits a path of node names separated by spaces or commas, as
+// written in CSV output.
+func ParsePath(text string) ([]string, error) {
+	path := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+	if len(path) == 0 {
+		return nil, fmt.Errorf("path is empty")
+	}
+	return path, nil
+}
+
 // Cost computes the cost of a solution.
 func (s *Solution) Cost() (int64, bool) {
 	var cost int64
@@ -20,3 +37,59 @@ func (s *Solution) Cost() (int64, bool) {
 	}
 	return cost, true
 }
+
+// Validate checks that a Solution is a feasible path for its Problem: it
+// goes from the start node to the end node, visits every node exactly
+// once, and visits every pickup before its delivery. It returns nil if the
+// path is feasible, or a ValidationError listing every issue found.
+func (s *Solution) Validate() error {
+	var errs ValidationError
+	addError := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	p := s.Problem
+	if len(s.Path) == 0 {
+		addError("path is empty")
+		return errs
+	}
+
+	if s.Path[0] != p.Start {
+		addError("path starts at %s instead of %s", s.Path[0], p.Start)
+	}
+	if s.Path[len(s.Path)-1] != p.End {
+		addError("path ends at %s instead of %s", s.Path[len(s.Path)-1], p.End)
+	}
+
+	position := map[string]int{}
+	for i, node := range s.Path {
+		if _, ok := p.Index(node); !ok {
+			addError("node %s is not in the problem", node)
+		} else if _, ok := position[node]; ok {
+			addError("node %s is visited more than once", node)
+		} else {
+			position[node] = i
+		}
+	}
+
+	for _, node := range p.Nodes {
+		if _, ok := position[node]; !ok {
+			addError("node %s is not visited", node)
+		}
+	}
+
+	for _, pickup := range s.Path {
+		delivery, ok := p.Precedence[pickup]
+		if !ok {
+			continue
+		}
+		if i, ok := position[delivery]; ok && i < position[pickup] {
+			addError("delivery %s is visited before pickup %s", delivery, pickup)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package solve
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/construct"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/inference"
+)
+
+// Options control how Solve searches. They mirror the flags of the
+// tsppd-dd command.
+type Options struct {
+	Form      string // Registered formulation, such as sequential, successor, or bidirectional
+	Infer     string // Registered inference duals joined by +, such as ap or ap+arb, or none
+	Relax     string // Relaxation dual supported by the formulation, or none
+	Ordering  string // Decision ordering supported by the formulation
+	Width     uint   // Diagram width, or 0 for exact diagrams
+	Batch     int    // Batch size for parallelization
+	Workers   int    // Number of workers
+	MaxMillis uint64 // Max milliseconds for search, or 0 for no limit
+	MaxNodes  uint64 // Max nodes and fails for search, or 0 for no limit
+	Verbosity uint   // 0 = quiet, 1 = solutions, 2 = layer construction
+
+	Construct  string // Initial incumbent heuristic: nearest, cheapest, regret, or ""
+	Local      bool   // Improve incumbents with local search
+	Preprocess bool   // Eliminate arcs by precedence and reduced cost
+	Debug      bool   // Verify every incumbent, and fail on invalid ones
+
+	LNS      string // Large neighborhood search for sequential form: window, random, or ""
+	LNSNodes uint64 // Max nodes and fails for each LNS sub-problem
+	LNSPairs int    // Pickup and delivery pairs freed in each LNS neighborhood
+	Seed     int64  // Random seed
+
+	// Logger is called with each new incumbent, if it isn't nil.
+	Logger ddo.Logger
+	// Log receives preprocessing messages, if it isn't nil.
+	Log io.Writer
+}
+
+// DefaultOptions returns options for an exact sequential search.
+func DefaultOptions() Options {
+	return Options{
+		Form:     "sequential",
+		Infer:    "none",
+		Relax:    "none",
+		Batch:    1,
+		Workers:  1,
+		LNSNodes: 1000,
+		LNSPairs: 4,
+	}
+}
+
+// Validate returns an error if options don't describe a valid search.
+func (o *Options) Validate() error {
+	if o.Batch < 1 {
+		return fmt.Errorf("batch size must be >= 1")
+	}
+
+	formulation, err := registry.LookupFormulation(o.Form)
+	if err != nil {
+		return fmt.Errorf("valid formulation required")
+	}
+
+	if o.Construct != "" {
+		if _, err := construct.Lookup(o.Construct); err != nil {
+			return err
+		}
+	}
+
+	if !inference.IsValid(o.Infer) {
+		return fmt.Errorf("invalid inference dual form")
+	}
+
+	ordering, ok := formulation.Ordering(o.Ordering)
+	if !ok && !(o.Ordering == "" && formulation.OrderingOptional) {
+		return fmt.Errorf("%s form requires valid decision ordering", o.Form)
+	}
+
+	if ordering.RequiresInference && o.Infer == "none" {
+		return fmt.Errorf("%s ordering requires an inference dual", o.Ordering)
+	}
+
+	if !formulation.SupportsRelaxation(o.Relax) {
+		return fmt.Errorf("invalid relaxation dual form")
+	}
+
+	if o.LNS != "" {
+		if o.LNS != "window" && o.LNS != "random" {
+			return fmt.Errorf("invalid lns neighborhood")
+		}
+		if o.Form != "sequential" {
+			return fmt.Errorf("lns requires sequential form")
+		}
+		if o.MaxMillis == 0 {
+			return fmt.Errorf("lns requires maxmillis")
+		}
+		if o.LNSPairs < 1 {
+			return fmt.Errorf("lnspairs must be >= 1")
+		}
+	}
+
+	if o.Workers < 1 {
+		return fmt.Errorf("workers must be >= 1")
+	}
+
+	return nil
+}
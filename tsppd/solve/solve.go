@@ -0,0 +1,222 @@
+// Package solve searches for TSPPD solutions using any formulation, with
+// the same options as the tsppd-dd command, for use as a library.
+package solve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/construct"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/lns"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/local"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/preprocess"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/sequential"
+
+	// Built in formulations register themselves.
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/bidirectional"
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/successor"
+)
+
+// Result gives the outcome of a search.
+type Result struct {
+	Incumbent  ddo.State       // Best state found, or nil
+	Solution   *tsppd.Solution // Path of the incumbent, or nil
+	Statistics ddo.Statistics
+}
+
+// ReadProblem decodes and validates a problem. Validation failures are
+// returned as a tsppd.ValidationError.
+func ReadProblem(r io.Reader) (*tsppd.Problem, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	problem, err := tsppd.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := problem.Validate(); err != nil {
+		return nil, err
+	}
+	return &problem, nil
+}
+
+// Solve searches for a minimum cost path through a problem. Search ends
+// when it proves optimality or infeasibility, reaches a limit in options,
+// or ctx is done, and returns the best solution found. A panic during
+// search, in any worker, is returned as an error.
+func Solve(ctx context.Context, problem *tsppd.Problem, options Options) (result *Result, err error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("solve: %v", r)
+		}
+	}()
+
+	log := options.Log
+	if log == nil {
+		log = ioutil.Discard
+	}
+
+	var preprocessor *preprocess.Preprocessor
+	if options.Preprocess {
+		preprocessor = preprocess.Preprocess(problem)
+		problem = preprocessor.Problem()
+		fmt.Fprintf(log, "preprocess: %d arcs eliminated by precedence\n", preprocessor.Precedence)
+	}
+
+	formulation, _ := registry.LookupFormulation(options.Form)
+	root := formulation.CreateRootState(
+		problem,
+		options.Infer,
+		options.Relax,
+		options.Ordering,
+		options.Width,
+		options.Verbosity,
+	)
+	fromSolution := func(solution *tsppd.Solution) ddo.State {
+		return formulation.CreateSolutionState(root, solution)
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = func(*ddo.Bounds, ddo.Statistics) {}
+	}
+
+	if preprocessor != nil {
+		next := logger
+		logger = func(bounds *ddo.Bounds, stats ddo.Statistics) {
+			if bounds.Primal != nil && !stats.Optimal {
+				fixed := preprocessor.Fix(bounds.Primal.Cost())
+				fmt.Fprintf(log, "preprocess: %d arcs eliminated by reduced cost (%d total)\n", fixed, preprocessor.Eliminated())
+			}
+			next(bounds, stats)
+		}
+	}
+
+	// Search is stopped on the first invalid incumbent in debug mode, and
+	// the error is returned once it ends.
+	var debugErr error
+	var stop func()
+	if options.Debug {
+		next := logger
+		logger = func(bounds *ddo.Bounds, stats ddo.Statistics) {
+			if bounds.Primal == nil || debugErr != nil {
+				next(bounds, stats)
+				return
+			}
+			if err := check(bounds.Primal); err != nil {
+				debugErr = err
+				stop()
+				return
+			}
+			next(bounds, stats)
+		}
+	}
+
+	var improver func(ddo.State) ddo.State
+	if options.Local {
+		improver = func(state ddo.State) ddo.State {
+			solution, ok := local.Improve(state.(tsppd.State).Solution())
+			if !ok {
+				return nil
+			}
+			return fromSolution(solution)
+		}
+	}
+
+	var incumbent ddo.State
+	if options.Construct != "" {
+		heuristic, _ := construct.Lookup(options.Construct)
+		if solution := heuristic(problem); solution != nil {
+			incumbent = fromSolution(solution)
+		} else {
+			fmt.Fprintln(log, "construct: no feasible solution found")
+		}
+	}
+
+	var stats ddo.Statistics
+	if options.LNS != "" {
+		search := lns.CreateSearch(root.(*sequential.State), options.Seed, logger)
+		search.Batch = options.Batch
+		search.Workers = options.Workers
+		search.Pairs = options.LNSPairs
+		search.Window = options.LNS == "window"
+		search.MaxNodes = options.LNSNodes
+		search.MaxMillis = options.MaxMillis
+		search.Improver = improver
+
+		stop = search.Stop
+		defer stopWhenDone(ctx, stop)()
+		incumbent = search.Run(incumbent)
+		stats = search.Statistics()
+
+	} else {
+		solver := ddo.CreateSolver(root, logger)
+		solver.Batch = options.Batch
+		solver.Workers = options.Workers
+		solver.MaxMillis = options.MaxMillis
+		solver.MaxNodes = options.MaxNodes
+		solver.Improver = improver
+
+		stop = solver.Stop
+		defer stopWhenDone(ctx, stop)()
+		if incumbent != nil {
+			solver.SetIncumbent(incumbent)
+		}
+
+		incumbent = solver.Minimize()
+		stats = solver.Statistics()
+	}
+
+	if debugErr != nil {
+		return nil, debugErr
+	}
+
+	result = &Result{Incumbent: incumbent, Statistics: stats}
+	if incumbent != nil {
+		result.Solution = incumbent.(tsppd.State).Solution()
+	}
+	return result, nil
+}
+
+// check validates an incumbent and confirms its path has the cost search
+// reports for it.
+func check(incumbent ddo.State) error {
+	solution := incumbent.(tsppd.State).Solution()
+	if err := solution.Validate(); err != nil {
+		return fmt.Errorf("invalid incumbent: %v", err)
+	}
+	cost, ok := solution.Cost()
+	if !ok {
+		return fmt.Errorf("invalid incumbent: path cost can't be computed")
+	}
+	if cost != incumbent.Cost() {
+		return fmt.Errorf("invalid incumbent: path cost %d does not match search cost %d", cost, incumbent.Cost())
+	}
+	return nil
+}
+
+// stopWhenDone calls stop once ctx is done. The function it returns
+// releases the goroutine waiting on ctx, and should be called once search
+// ends.
+func stopWhenDone(ctx context.Context, stop func()) func() {
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-finished:
+		}
+	}()
+	return func() { close(finished) }
+}
@@ -0,0 +1,304 @@
+package solve_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/generate"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/reference"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solve"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/sequential"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/successor"
+)
+
+// stubDepth is the depth of solved stub states.
+const stubDepth = 3
+
+// stubState is a search tree that tests how Solve handles its workers.
+// Restricting a state sleeps, or panics.
+type stubState struct {
+	depth  int
+	sleep  time.Duration
+	panics bool
+}
+
+func (s *stubState) Cost() int64               { return int64(s.depth) }
+func (s *stubState) IsSolved() bool            { return s.depth == stubDepth }
+func (s *stubState) Infer() *ddo.Diagram       { return nil }
+func (s *stubState) Relax() *ddo.Diagram       { return nil }
+func (s *stubState) Solution() *tsppd.Solution { return &tsppd.Solution{} }
+
+func (s *stubState) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
+	if s.IsSolved() {
+		return nil
+	}
+	child := &stubState{depth: s.depth + 1, sleep: s.sleep, panics: s.panics}
+	return []ddo.State{child, child}
+}
+
+func (s *stubState) Restrict() *ddo.Diagram {
+	if s.panics {
+		panic("restriction failed")
+	}
+	time.Sleep(s.sleep)
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+func init() {
+	for name, root := range map[string]*stubState{
+		"stub-slow":  {sleep: 10 * time.Millisecond},
+		"stub-panic": {panics: true},
+	} {
+		root := root
+		registry.RegisterFormulation(registry.Formulation{
+			Component:        registry.Component{Name: name, Description: "stub for testing"},
+			OrderingOptional: true,
+			CreateRootState: func(*tsppd.Problem, string, string, string, uint, uint) ddo.State {
+				return root
+			},
+			CreateSolutionState: func(root ddo.State, solution *tsppd.Solution) ddo.State {
+				return root
+			},
+		})
+	}
+}
+
+// Calls to orderings and mergers registered by tests for the built in
+// formulations.
+var (
+	reverseCalls int64
+	mergeCalls   int64
+)
+
+func reverse(candidates []int) {
+	atomic.AddInt64(&reverseCalls, 1)
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+}
+
+func init() {
+	registry.RegisterOrdering("sequential", registry.Ordering{
+		Component: registry.Component{Name: "test-reverse", Description: "next nodes in reverse input order"},
+		Create: func(*tsppd.Problem) interface{} {
+			return sequential.Orderer(func(s *sequential.State, candidates []int, inferenceDual ddo.State) {
+				reverse(candidates)
+			})
+		},
+	})
+	registry.RegisterOrdering("successor", registry.Ordering{
+		Component: registry.Component{Name: "test-reverse", Description: "nodes in reverse input order"},
+		Create: func(problem *tsppd.Problem) interface{} {
+			return successor.StaticOrdering(func(s *successor.State) []int {
+				ordering := []int{}
+				for index := range problem.Nodes {
+					if index != problem.EndIndex() {
+						ordering = append(ordering, index)
+					}
+				}
+				reverse(ordering)
+				return ordering
+			})
+		},
+	})
+	registry.RegisterRelaxation("sequential", registry.Relaxation{
+		Component: registry.Component{Name: "test-dd", Description: "counts merges"},
+		Create: func(*tsppd.Problem) []ddo.Merger {
+			return []ddo.Merger{func(states []ddo.State, width uint) []ddo.State {
+				atomic.AddInt64(&mergeCalls, 1)
+				return sequential.MaxCostRelaxationMerger(states, width)
+			}}
+		},
+	})
+}
+
+func createProblem(t *testing.T) *tsppd.Problem {
+	problem, err := generate.Generate(generate.Options{
+		Pairs:        3,
+		Distribution: "depot-centric",
+		Metric:       "euclidean",
+		Seed:         1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return problem
+}
+
+func TestSolve(t *testing.T) {
+	expected, _ := reference.Solve(createProblem(t))
+	optimal, _ := expected.Cost()
+
+	for _, form := range []string{"sequential", "successor", "bidirectional"} {
+		options := solve.DefaultOptions()
+		options.Form = form
+		options.Infer = "ap"
+		options.Debug = true
+		if form == "successor" {
+			options.Ordering = "greedy"
+		}
+
+		result, err := solve.Solve(context.Background(), createProblem(t), options)
+		if err != nil {
+			t.Fatalf("%s: %v", form, err)
+		}
+		if cost, _ := result.Solution.Cost(); cost != optimal || !result.Statistics.Optimal {
+			t.Errorf("%s: cost %d, optimal %t, expected %d", form, cost, result.Statistics.Optimal, optimal)
+		}
+	}
+}
+
+func TestSolveRegisteredComponents(t *testing.T) {
+	expected, _ := reference.Solve(createProblem(t))
+	optimal, _ := expected.Cost()
+
+	for _, form := range []string{"sequential", "successor"} {
+		options := solve.DefaultOptions()
+		options.Form = form
+		options.Ordering = "test-reverse"
+		options.Width = 2
+		if form == "sequential" {
+			options.Relax = "test-dd"
+		}
+
+		atomic.StoreInt64(&reverseCalls, 0)
+		atomic.StoreInt64(&mergeCalls, 0)
+		result, err := solve.Solve(context.Background(), createProblem(t), options)
+		if err != nil {
+			t.Fatalf("%s: %v", form, err)
+		}
+		if cost, _ := result.Solution.Cost(); cost != optimal || !result.Statistics.Optimal {
+			t.Errorf("%s: cost %d, optimal %t, expected %d", form, cost, result.Statistics.Optimal, optimal)
+		}
+		if atomic.LoadInt64(&reverseCalls) == 0 {
+			t.Errorf("%s: registered ordering not used", form)
+		}
+		if form == "sequential" && atomic.LoadInt64(&mergeCalls) == 0 {
+			t.Errorf("%s: registered relaxation not used", form)
+		}
+	}
+}
+
+// TestSolveLNSWithoutIncumbent checks that LNS constructs an incumbent
+// when a node-limited search of the full problem doesn't find one. The
+// restriction takes the cheap arc to +1, and then can't reach +2 from -1.
+func TestSolveLNSWithoutIncumbent(t *testing.T) {
+	problem, err := solve.ReadProblem(strings.NewReader(`{
+		"Nodes": ["+0", "-0", "+1", "-1", "+2", "-2"],
+		"Precedence": {"+1": "-1", "+2": "-2"},
+		"Edges": [
+			[0, 9, 1, 9, 50, 9],
+			[9, 0, 9, 9, 9, 9],
+			[9, 9, 0, 1, 9, 9],
+			[9, 5, 9, 0, 9, 9],
+			[9, 9, 9, 9, 0, 1],
+			[9, 1, 9, 9, 9, 0]
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem.RemoveArc(3, 4)
+
+	options := solve.DefaultOptions()
+	options.LNS = "random"
+	options.LNSNodes = 1
+	options.Width = 1
+	options.MaxMillis = 100
+	result, err := solve.Solve(context.Background(), problem, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Solution == nil {
+		t.Fatal("expected a constructed solution")
+	}
+	if err := result.Solution.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSolveCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	options := solve.DefaultOptions()
+	options.Construct = "regret"
+	result, err := solve.Solve(ctx, createProblem(t), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Solution == nil {
+		t.Error("expected the constructed solution")
+	}
+}
+
+func TestSolveInvalidOptions(t *testing.T) {
+	options := solve.DefaultOptions()
+	options.Form = "successor"
+	if _, err := solve.Solve(context.Background(), createProblem(t), options); err == nil {
+		t.Error("expected an error for successor form without an ordering")
+	}
+}
+
+func TestSolveWorkerPanic(t *testing.T) {
+	options := solve.DefaultOptions()
+	options.Form = "stub-panic"
+	options.Workers = 4
+	if _, err := solve.Solve(context.Background(), createProblem(t), options); err == nil {
+		t.Error("expected an error from a panic in a worker")
+	}
+}
+
+// TestSolveTimeLimitWithinLayer checks that an exact search stops at its
+// time limit while building a large layer.
+func TestSolveTimeLimitWithinLayer(t *testing.T) {
+	problem, err := generate.Generate(generate.Options{
+		Pairs:        14,
+		Distribution: "uniform",
+		Metric:       "euclidean",
+		Seed:         9,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := solve.DefaultOptions()
+	options.MaxMillis = 200
+	start := time.Now()
+	if _, err := solve.Solve(context.Background(), problem, options); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("search took %v with a 200ms time limit", elapsed)
+	}
+}
+
+func TestSolveStopsWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// The time limit passes while the first batch is bounded, so search
+	// stops without reading results from later batches.
+	options := solve.DefaultOptions()
+	options.Form = "stub-slow"
+	options.Workers = 4
+	options.MaxMillis = 1
+	for i := 0; i < 3; i++ {
+		if _, err := solve.Solve(context.Background(), createProblem(t), options); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines before search, %d after", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,222 @@
+package apdual
+
+import (
+	"math"
+
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+)
+
+// assignment is an assignment problem that is reoptimized incrementally.
+// Forcing or removing arcs keeps the dual potentials feasible, so only
+// rows that lose their assigned column need to be repaired, with one
+// O(n^2) shortest augmenting path each.
+//
+// Changing the costs of a row lowers or raises its potential to keep it
+// dual feasible, so only that row may need to be repaired.
+//
+// Copies share the rows of the cost matrix and any sets of removed arcs
+// they don't modify. Copying an assignment is O(n).
+type assignment struct {
+	costs   [][]int64     // Rows are shared by all copies and replaced, never modified
+	removed []*bitset.Set // removed[i] = columns removed from row i, copied on write
+	forced  []int         // forced[j] = only row that can assign to column j, or -1
+	big     int64         // Cost of removed arcs
+
+	u   []int64 // u[i] = dual potential of row i
+	v   []int64 // v[j] = dual potential of column j
+	col []int   // col[i] = column assigned to row i, or -1
+	row []int   // row[j] = row assigned to column j, or -1
+	z   int64
+}
+
+func createAssignment(costs [][]int64, big int64) *assignment {
+	n := len(costs)
+	a := &assignment{
+		costs:   costs,
+		big:     big,
+		removed: make([]*bitset.Set, n),
+		forced:  make([]int, n),
+		u:       make([]int64, n),
+		v:       make([]int64, n),
+		col:     make([]int, n),
+		row:     make([]int, n),
+	}
+
+	empty := bitset.New(n)
+	for i := 0; i < n; i++ {
+		a.removed[i] = empty
+		a.forced[i] = -1
+		a.col[i] = -1
+		a.row[i] = -1
+
+		// Row reduction makes the initial potentials dual feasible.
+		a.u[i] = math.MaxInt64
+		for j := 0; j < n; j++ {
+			if costs[i][j] < a.u[i] {
+				a.u[i] = costs[i][j]
+			}
+		}
+	}
+
+	return a
+}
+
+func (a *assignment) copy() *assignment {
+	return &assignment{
+		costs:   a.costs,
+		removed: append([]*bitset.Set(nil), a.removed...),
+		forced:  append([]int(nil), a.forced...),
+		big:     a.big,
+		u:       append([]int64(nil), a.u...),
+		v:       append([]int64(nil), a.v...),
+		col:     append([]int(nil), a.col...),
+		row:     append([]int(nil), a.row...),
+		z:       a.z,
+	}
+}
+
+// cost returns the current cost of arc (i j), which is big if it has
+// been removed.
+func (a *assignment) cost(i, j int) int64 {
+	if a.removed[i].Contains(j) || (a.forced[j] >= 0 && a.forced[j] != i) {
+		return a.big
+	}
+	return a.costs[i][j]
+}
+
+// rc returns the reduced cost of arc (i j).
+func (a *assignment) rc(i, j int) int64 {
+	return a.cost(i, j) - a.u[i] - a.v[j]
+}
+
+// force removes every arc into column j except (i j).
+func (a *assignment) force(i, j int) {
+	a.forced[j] = i
+	if r := a.row[j]; r >= 0 && r != i {
+		a.unassign(r)
+	}
+}
+
+// remove takes arc (i j) out of the feasible set.
+func (a *assignment) remove(i, j int) {
+	if a.removed[i].Contains(j) {
+		return
+	}
+	a.removed[i] = a.removed[i].Copy()
+	a.removed[i].Add(j)
+	if a.col[i] == j {
+		a.unassign(i)
+	}
+}
+
+// removeAll takes arcs from row i to each column in cols out of the feasible set.
+func (a *assignment) removeAll(i int, cols *bitset.Set) {
+	a.removed[i] = a.removed[i].Union(cols)
+	if j := a.col[i]; j >= 0 && cols.Contains(j) {
+		a.unassign(i)
+	}
+}
+
+// reprice replaces the costs of each row in rows. Each changed row gets the
+// largest potential that keeps it dual feasible, and is unassigned if its
+// assigned arc is no longer tight.
+func (a *assignment) reprice(rows map[int][]int64) {
+	a.costs = append([][]int64(nil), a.costs...)
+	for i, costs := range rows {
+		a.costs[i] = costs
+
+		a.u[i] = math.MaxInt64
+		for j := range costs {
+			if r := a.cost(i, j) - a.v[j]; r < a.u[i] {
+				a.u[i] = r
+			}
+		}
+		if j := a.col[i]; j >= 0 && a.rc(i, j) != 0 {
+			a.unassign(i)
+		}
+	}
+}
+
+func (a *assignment) unassign(i int) {
+	a.row[a.col[i]] = -1
+	a.col[i] = -1
+}
+
+// solve assigns every unassigned row and updates the objective value.
+func (a *assignment) solve() {
+	for i := range a.col {
+		if a.col[i] < 0 {
+			a.augment(i)
+		}
+	}
+
+	a.z = 0
+	for i := range a.u {
+		a.z += a.u[i] + a.v[i]
+	}
+}
+
+// augment assigns row i0 along a shortest augmenting path in the reduced
+// cost graph, adjusting dual potentials as it goes.
+func (a *assignment) augment(i0 int) {
+	n := len(a.col)
+	slack := make([]int64, n) // slack[j] = min reduced cost of reaching column j
+	prev := make([]int, n)    // prev[j] = column preceding j on the path, or -1
+	used := make([]bool, n)
+	for j := 0; j < n; j++ {
+		slack[j] = math.MaxInt64
+		prev[j] = -1
+	}
+
+	j0 := -1
+	for {
+		i := i0
+		if j0 >= 0 {
+			used[j0] = true
+			i = a.row[j0]
+		}
+
+		var delta int64 = math.MaxInt64
+		j1 := -1
+		for j := 0; j < n; j++ {
+			if used[j] {
+				continue
+			}
+			if r := a.rc(i, j); r < slack[j] {
+				slack[j] = r
+				prev[j] = j0
+			}
+			if slack[j] < delta {
+				delta = slack[j]
+				j1 = j
+			}
+		}
+
+		a.u[i0] += delta
+		for j := 0; j < n; j++ {
+			if used[j] {
+				a.u[a.row[j]] += delta
+				a.v[j] -= delta
+			} else {
+				slack[j] -= delta
+			}
+		}
+
+		j0 = j1
+		if a.row[j0] < 0 {
+			break
+		}
+	}
+
+	// Flip assignments along the path back to i0.
+	for j0 >= 0 {
+		j1 := prev[j0]
+		if j1 >= 0 {
+			a.row[j0] = a.row[j1]
+		} else {
+			a.row[j0] = i0
+		}
+		a.col[a.row[j0]] = j0
+		j0 = j1
+	}
+}
@@ -0,0 +1,18 @@
+package apdual
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+func init() {
+	registry.RegisterInferenceDual(registry.InferenceDual{
+		Component: registry.Component{
+			Name:        "ap",
+			Description: "assignment problem relaxation with reduced cost filtering",
+		},
+		Create: func(problem *tsppd.Problem) tsppd.InferenceDual {
+			return CreateAPDualState(problem)
+		},
+	})
+}
@@ -1,23 +1,21 @@
 package apdual
 
 import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
 	"github.com/ryanjoneil/tsppd-dd/ddo"
 	"github.com/ryanjoneil/tsppd-dd/tsppd"
-	"github.com/ryanjoneil/ap"
 )
 
-const big = 10 * 1000 * 1000
-
 // State represents a current AP relaxation.
 type State struct {
-	ap      *ap.AP
+	ap      *assignment
 	problem *tsppd.Problem
 }
 
 // CreateAPDualState creates a State that maintains an AP formulation.
 func CreateAPDualState(problem *tsppd.Problem) *State {
 	ap := createAP(problem)
-	ap.Solve()
+	ap.solve()
 
 	return &State{
 		ap:      ap,
@@ -25,6 +23,31 @@ func CreateAPDualState(problem *tsppd.Problem) *State {
 	}
 }
 
+// Branch returns a new AP State with an edge forced on and the edges from
+// each index in from to each index in to forced off.
+func (s *State) Branch(index1, index2 int, from, to *bitset.Set) tsppd.InferenceDual {
+	newState := s.Copy()
+	newState.Force(index1, index2)
+	if from != nil && to != nil {
+		for index3 := from.Min(); index3 >= 0; index3 = from.Next(index3 + 1) {
+			newState.RemoveAll(index3, to)
+		}
+	}
+	newState.Solve()
+	return newState
+}
+
+// Reprice returns a new AP State with the arc costs of each row in rows
+// replaced, keeping any forced or removed edges. It reoptimizes from the
+// current solution, repairing only rows whose assigned edge is no longer
+// tight under the new costs.
+func (s *State) Reprice(rows map[int][]int64) *State {
+	newState := s.Copy()
+	newState.ap.reprice(rows)
+	newState.Solve()
+	return newState
+}
+
 // Set returns a new AP State with an edge forced on.
 func (s *State) Set(node1, node2 string) *State {
 	index1, _ := s.problem.Index(node1)
@@ -35,11 +58,7 @@ func (s *State) Set(node1, node2 string) *State {
 // SetIndex returns a new AP State with an edge forced on.
 func (s *State) SetIndex(index1, index2 int) *State {
 	newState := s.Copy()
-	for index3 := 0; index3 < len(s.problem.Nodes); index3++ {
-		if index3 != index1 {
-			newState.Remove(index3, index2)
-		}
-	}
+	newState.Force(index1, index2)
 	newState.Solve()
 	return newState
 }
@@ -54,7 +73,7 @@ func (s *State) UnsetIndex(index1, index2 int) *State {
 
 // Cost returns the objective value of an AP.
 func (s *State) Cost() int64 {
-	return s.ap.Z
+	return s.ap.z
 }
 
 // IsSolved will always be true.
@@ -97,39 +116,71 @@ func (s *State) FilterIndex(index1, index2 int, incumbent ddo.State) bool {
 	if incumbent == nil {
 		return false
 	}
-	return s.ap.Z+s.ap.RC(index1, index2) >= incumbent.Cost()
+	return s.ap.z+s.ap.rc(index1, index2) >= incumbent.Cost()
+}
+
+// RC returns the reduced cost of an edge in the AP relaxation.
+func (s *State) RC(index1, index2 int) int64 {
+	return s.ap.rc(index1, index2)
+}
+
+// ArcCost returns the cost of an edge in the AP, which is large if the
+// edge has been forced off.
+func (s *State) ArcCost(index1, index2 int) int64 {
+	return s.ap.cost(index1, index2)
 }
 
-func createAP(problem *tsppd.Problem) *ap.AP {
-	ap := ap.Create(len(problem.Nodes))
+// Successor returns the index assigned to follow index in the AP solution.
+func (s *State) Successor(index int) int {
+	return s.ap.col[index]
+}
+
+func createAP(problem *tsppd.Problem) *assignment {
+	big := problem.InfeasibleCost()
+	costs := make([][]int64, len(problem.Nodes))
 	for i := range problem.Nodes {
+		costs[i] = make([]int64, len(problem.Nodes))
 		for j := range problem.Nodes {
-			node1 := problem.Nodes[i]
-			node2 := problem.Nodes[j]
-			if problem.IsFeasible(node1, node2) || node1 == "-0" && node2 == "+0" {
-				ap.A[i][j], _ = problem.Cost(node1, node2)
+			if i == problem.EndIndex() && j == problem.StartIndex() {
+				// A free arc from the end to the start closes paths into tours.
+				costs[i][j] = 0
+			} else if problem.IsFeasibleIndex(i, j) {
+				costs[i][j] = problem.Edges[i][j]
 			} else {
-				ap.A[i][j] = big
+				costs[i][j] = big
 			}
 		}
 	}
-	return ap
+	return createAssignment(costs, big)
 }
 
-// Copy makes a complete copy of the state.
+// Copy makes a copy of the state. Copies share costs and unmodified sets
+// of removed edges, so this is O(n).
 func (s *State) Copy() *State {
 	return &State{
-		ap:      s.ap.Copy(),
+		ap:      s.ap.copy(),
 		problem: s.problem,
 	}
 }
 
+// Force removes every edge into index2 except the one from index1.
+func (s *State) Force(index1, index2 int) {
+	s.ap.force(index1, index2)
+}
+
 // Remove takes an edge out of the feasible set by giving it a large cost.
 func (s *State) Remove(index1, index2 int) {
-	s.ap.Remove(index1, index2, big)
+	s.ap.remove(index1, index2)
+}
+
+// RemoveAll takes the edges from index1 to each index in indices out of
+// the feasible set.
+func (s *State) RemoveAll(index1 int, indices *bitset.Set) {
+	s.ap.removeAll(index1, indices)
 }
 
-// Solve re-solves an AP relaxation.
+// Solve re-solves an AP relaxation, repairing only rows that lost their
+// assignment since the last solve.
 func (s *State) Solve() {
-	s.ap.Solve()
+	s.ap.solve()
 }
@@ -0,0 +1,358 @@
+package arbdual
+
+import (
+	"math"
+
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+)
+
+// arborescence is a minimum spanning arborescence problem rooted at a
+// given node, solved with Edmonds' algorithm as a dual ascent. Each set of
+// nodes in a laminar family has a dual value, starting with single nodes.
+// The reduced cost of an arc is its cost less the duals of every set it
+// enters. A set not containing the root raises its dual until an arc
+// entering it has zero reduced cost, and that arc is chosen to enter it.
+// A cycle of chosen arcs is contracted into a new set. The sum of the
+// duals is the cost of the minimum arborescence.
+//
+// Forcing off an arc only raises reduced costs, so the duals stay feasible.
+// If the arc was chosen to enter a set, the sets that contain both of its
+// nodes are expanded, since their cycles are broken, and the ascent
+// resumes from there. Other sets keep their duals and chosen arcs.
+//
+// Copies share the cost matrix, the reduced cost matrix until it changes,
+// and any sets of removed arcs they don't modify.
+type arborescence struct {
+	root    int
+	costs   [][]int64     // Shared by all copies and never modified
+	removed []*bitset.Set // removed[i] = heads of arcs removed from tail i, copied on write
+	forced  []int         // forced[j] = only tail that can connect to head j, or -1
+	big     int64         // Cost of removed arcs
+
+	// Sets 0 to n-1 are single nodes. Larger sets are contracted cycles.
+	parent []int   // parent[c] = set c was contracted into, or -1
+	dual   []int64 // dual[c] = dual value of set c
+	tail   []int   // tail[c] = tail of the arc chosen to enter set c, or -1
+	head   []int   // head[c] = head of the arc chosen to enter set c, or -1
+	free   []int   // Unused set numbers
+
+	rc      [][]int64 // rc[i][j] = costs[i][j] less the duals of sets (i j) enters
+	ownRC   bool      // true if rc isn't shared with other copies
+	z       int64
+	pending bool // true if a set has no chosen arc
+}
+
+func createArborescence(root int, costs [][]int64, big int64) *arborescence {
+	n := len(costs)
+	a := &arborescence{
+		root:    root,
+		costs:   costs,
+		removed: make([]*bitset.Set, n),
+		forced:  make([]int, n),
+		big:     big,
+		parent:  make([]int, n),
+		dual:    make([]int64, n),
+		tail:    make([]int, n),
+		head:    make([]int, n),
+		rc:      make([][]int64, n),
+		ownRC:   true,
+		pending: true,
+	}
+
+	empty := bitset.New(n)
+	for i := 0; i < n; i++ {
+		a.removed[i] = empty
+		a.forced[i] = -1
+		a.parent[i] = -1
+		a.tail[i] = -1
+		a.head[i] = -1
+		a.rc[i] = append([]int64(nil), costs[i]...)
+	}
+
+	return a
+}
+
+func (a *arborescence) copy() *arborescence {
+	return &arborescence{
+		root:    a.root,
+		costs:   a.costs,
+		removed: append([]*bitset.Set(nil), a.removed...),
+		forced:  append([]int(nil), a.forced...),
+		big:     a.big,
+		parent:  append([]int(nil), a.parent...),
+		dual:    append([]int64(nil), a.dual...),
+		tail:    append([]int(nil), a.tail...),
+		head:    append([]int(nil), a.head...),
+		free:    append([]int(nil), a.free...),
+		rc:      a.rc,
+		z:       a.z,
+		pending: a.pending,
+	}
+}
+
+// isRemoved returns true if arc (i j) has been forced off.
+func (a *arborescence) isRemoved(i, j int) bool {
+	return a.removed[i].Contains(j) || (a.forced[j] >= 0 && a.forced[j] != i)
+}
+
+// cost returns the current cost of arc (i j), which is big if it has
+// been forced off.
+func (a *arborescence) cost(i, j int) int64 {
+	if a.isRemoved(i, j) {
+		return a.big
+	}
+	return a.costs[i][j]
+}
+
+// reducedCost returns the reduced cost of arc (i j) at its current cost.
+func (a *arborescence) reducedCost(i, j int) int64 {
+	return a.rc[i][j] + a.cost(i, j) - a.costs[i][j]
+}
+
+// force removes every arc into head j except (i j).
+func (a *arborescence) force(i, j int) {
+	for k := range a.forced {
+		if k != i && k != j && !a.isRemoved(k, j) {
+			a.unchoose(k, j)
+		}
+	}
+	a.forced[j] = i
+}
+
+// removeAll takes arcs from tail i to each head in heads out of the feasible set.
+func (a *arborescence) removeAll(i int, heads *bitset.Set) {
+	for j := heads.Min(); j >= 0; j = heads.Next(j + 1) {
+		if i != j && !a.isRemoved(i, j) {
+			a.unchoose(i, j)
+		}
+	}
+	a.removed[i] = a.removed[i].Union(heads)
+}
+
+// unchoose is called before arc (i j) is forced off. If the arc was chosen
+// to enter a set, that set needs a new one, and the sets containing both i
+// and j are expanded.
+func (a *arborescence) unchoose(i, j int) {
+	for c := j; c >= 0; c = a.parent[c] {
+		if a.tail[c] == i && a.head[c] == j {
+			a.tail[c], a.head[c] = -1, -1
+			a.pending = true
+			if a.parent[c] >= 0 {
+				a.expand(a.parent[c])
+			}
+			return
+		}
+	}
+}
+
+// expand removes set c and every set containing it, along with their
+// duals. The sets they contain become outermost sets.
+func (a *arborescence) expand(c int) {
+	n := len(a.costs)
+	a.copyRC()
+
+	chain := []int{}
+	for ; c >= 0; c = a.parent[c] {
+		chain = append(chain, c)
+	}
+
+	// Members are found before any parents change.
+	members := make([][]bool, len(chain))
+	for k, d := range chain {
+		members[k] = a.members(d)
+	}
+
+	for k, d := range chain {
+		for j := 0; j < n; j++ {
+			if !members[k][j] {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				if !members[k][i] {
+					a.rc[i][j] += a.dual[d]
+				}
+			}
+		}
+		a.z -= a.dual[d]
+	}
+
+	expanded := map[int]bool{}
+	for _, d := range chain {
+		expanded[d] = true
+		a.free = append(a.free, d)
+	}
+	for c := range a.parent {
+		if expanded[a.parent[c]] {
+			a.parent[c] = -1
+		}
+	}
+}
+
+// members returns the nodes in set c.
+func (a *arborescence) members(c int) []bool {
+	n := len(a.costs)
+	inSet := make([]bool, n)
+	for v := 0; v < n; v++ {
+		for d := v; d >= 0; d = a.parent[d] {
+			if d == c {
+				inSet[v] = true
+				break
+			}
+		}
+	}
+	return inSet
+}
+
+// copyRC makes a copy of the reduced cost matrix before it changes, if it
+// is shared with other copies.
+func (a *arborescence) copyRC() {
+	if a.ownRC {
+		return
+	}
+	rc := make([][]int64, len(a.rc))
+	for i := range a.rc {
+		rc[i] = append([]int64(nil), a.rc[i]...)
+	}
+	a.rc = rc
+	a.ownRC = true
+}
+
+// createSet returns a new outermost set with no dual or chosen arc.
+func (a *arborescence) createSet() int {
+	if len(a.free) > 0 {
+		c := a.free[len(a.free)-1]
+		a.free = a.free[:len(a.free)-1]
+		a.parent[c], a.dual[c], a.tail[c], a.head[c] = -1, 0, -1, -1
+		return c
+	}
+	a.parent = append(a.parent, -1)
+	a.dual = append(a.dual, 0)
+	a.tail = append(a.tail, -1)
+	a.head = append(a.head, -1)
+	return len(a.parent) - 1
+}
+
+// outermost returns the outermost set containing each node.
+func (a *arborescence) outermost() []int {
+	comp := make([]int, len(a.costs))
+	for v := range comp {
+		c := v
+		for a.parent[c] >= 0 {
+			c = a.parent[c]
+		}
+		comp[v] = c
+	}
+	return comp
+}
+
+// solve resumes the dual ascent until each outermost set has a chosen
+// arc and they form no cycles.
+func (a *arborescence) solve() {
+	if !a.pending {
+		return
+	}
+	a.pending = false
+
+	n := len(a.costs)
+	for {
+		// Each outermost set except the root's chooses its cheapest
+		// entering arc. Its reduced cost is added to the dual of the set,
+		// and taken out of the reduced cost of every arc entering it.
+		comp := a.outermost()
+		minIn := make([]int64, len(a.parent))
+		for c := range minIn {
+			minIn[c] = math.MaxInt64
+		}
+		for j := 0; j < n; j++ {
+			c := comp[j]
+			if c == comp[a.root] {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				if comp[i] == c {
+					continue
+				}
+				if r := a.reducedCost(i, j); r < minIn[c] {
+					minIn[c] = r
+					a.tail[c], a.head[c] = i, j
+				}
+			}
+		}
+
+		raised := false
+		for c, r := range minIn {
+			if r != math.MaxInt64 && r > 0 {
+				a.dual[c] += r
+				a.z += r
+				raised = true
+			}
+		}
+		if raised {
+			a.copyRC()
+			for j := 0; j < n; j++ {
+				c := comp[j]
+				if minIn[c] == math.MaxInt64 || minIn[c] == 0 {
+					continue
+				}
+				for i := 0; i < n; i++ {
+					if comp[i] != c {
+						a.rc[i][j] -= minIn[c]
+					}
+				}
+			}
+		}
+
+		// Contract each cycle of chosen arcs into a new set.
+		in := make([]int, len(a.parent))
+		for c := range in {
+			in[c] = -1
+			if minIn[c] != math.MaxInt64 {
+				in[c] = comp[a.tail[c]]
+			}
+		}
+		cycles := findCycles(in)
+		if len(cycles) == 0 {
+			break
+		}
+		for _, cycle := range cycles {
+			set := a.createSet()
+			for _, c := range cycle {
+				a.parent[c] = set
+			}
+		}
+	}
+}
+
+// findCycles returns the cycles in a graph where each node c has at most
+// one outgoing arc, to in[c], or none if in[c] < 0.
+func findCycles(in []int) [][]int {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	color := make([]int, len(in))
+	cycles := [][]int{}
+	for start := range in {
+		path := []int{}
+		for c := start; in[c] >= 0 && color[c] != done; c = in[c] {
+			if color[c] == visiting {
+				// Found a cycle: the path suffix starting at c.
+				for k := len(path) - 1; k >= 0; k-- {
+					if path[k] == c {
+						cycles = append(cycles, append([]int(nil), path[k:]...))
+						break
+					}
+				}
+				break
+			}
+			color[c] = visiting
+			path = append(path, c)
+		}
+		for _, c := range path {
+			color[c] = done
+		}
+	}
+	return cycles
+}
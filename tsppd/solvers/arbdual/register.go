@@ -0,0 +1,18 @@
+package arbdual
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+func init() {
+	registry.RegisterInferenceDual(registry.InferenceDual{
+		Component: registry.Component{
+			Name:        "arb",
+			Description: "minimum arborescence rooted at the start node",
+		},
+		Create: func(problem *tsppd.Problem) tsppd.InferenceDual {
+			return CreateArborescenceDualState(problem)
+		},
+	})
+}
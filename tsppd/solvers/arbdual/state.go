@@ -0,0 +1,107 @@
+// Package arbdual bounds TSPPD paths with a minimum arborescence rooted at
+// the start node. The arborescence is found with Edmonds' algorithm, and
+// repaired incrementally after branching: only the contracted cycles that
+// lose a chosen arc are expanded and solved again.
+package arbdual
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// State represents a current minimum 1-arborescence relaxation. Every
+// feasible path is an arborescence rooted at the start node, so its
+// minimum cost is a lower bound that captures connectivity the AP misses.
+type State struct {
+	arb     *arborescence
+	problem *tsppd.Problem
+}
+
+// CreateArborescenceDualState creates a State that maintains a minimum
+// arborescence rooted at the start node.
+func CreateArborescenceDualState(problem *tsppd.Problem) *State {
+	big := problem.InfeasibleCost()
+	costs := make([][]int64, len(problem.Nodes))
+	for i := range problem.Nodes {
+		costs[i] = make([]int64, len(problem.Nodes))
+		for j := range problem.Nodes {
+			if problem.IsFeasibleIndex(i, j) {
+				costs[i][j] = problem.Edges[i][j]
+			} else {
+				costs[i][j] = big
+			}
+		}
+	}
+
+	arb := createArborescence(problem.StartIndex(), costs, big)
+	arb.solve()
+
+	return &State{
+		arb:     arb,
+		problem: problem,
+	}
+}
+
+// Branch returns a new arborescence State with an edge forced on and the
+// edges from each index in from to each index in to forced off.
+func (s *State) Branch(index1, index2 int, from, to *bitset.Set) tsppd.InferenceDual {
+	newState := &State{
+		arb:     s.arb.copy(),
+		problem: s.problem,
+	}
+	newState.arb.force(index1, index2)
+	if from != nil && to != nil {
+		for index3 := from.Min(); index3 >= 0; index3 = from.Next(index3 + 1) {
+			newState.arb.removeAll(index3, to)
+		}
+	}
+	newState.arb.solve()
+	return newState
+}
+
+// Cost returns the cost of the minimum arborescence.
+func (s *State) Cost() int64 {
+	return s.arb.z
+}
+
+// IsSolved will always be true.
+func (s *State) IsSolved() bool {
+	return true
+}
+
+// Next just returns the same state.
+func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
+	return []ddo.State{s}
+}
+
+// Infer doesn't do much for the arborescence since it is always optimal.
+func (s *State) Infer() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// Relax doesn't do much for the arborescence since it is always optimal.
+func (s *State) Relax() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// Restrict doesn't do much for the arborescence either.
+func (s *State) Restrict() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// FilterIndex returns true if the given edge can't be in an optimal solution.
+func (s *State) FilterIndex(index1, index2 int, incumbent ddo.State) bool {
+	if incumbent == nil {
+		return false
+	}
+	return s.arb.isRemoved(index1, index2) || s.arb.z+s.arb.reducedCost(index1, index2) >= incumbent.Cost()
+}
+
+// RC returns the reduced cost of an edge in the arborescence relaxation.
+func (s *State) RC(index1, index2 int) int64 {
+	if s.arb.isRemoved(index1, index2) {
+		return s.arb.big
+	}
+	return s.arb.reducedCost(index1, index2)
+}
@@ -0,0 +1,23 @@
+package bidirectional
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+func init() {
+	registry.RegisterFormulation(registry.Formulation{
+		Component: registry.Component{
+			Name:        "bidirectional",
+			Description: "alternates between extending routes forward from the start and backward from the end",
+		},
+		OrderingOptional: true,
+		CreateRootState: func(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) ddo.State {
+			return CreateRootState(problem, infer, relax, ordering, width, verbosity)
+		},
+		CreateSolutionState: func(root ddo.State, solution *tsppd.Solution) ddo.State {
+			return CreateSolutionState(root.(*State), solution)
+		},
+	})
+}
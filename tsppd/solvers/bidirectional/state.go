@@ -0,0 +1,275 @@
+package bidirectional
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/inference"
+)
+
+// State represents a path built forward from the start node and a path
+// built backward from the end node. Extensions alternate between the two
+// directions until every node is on one path, then the paths are joined.
+type State struct {
+	cost      int64
+	forward   *bitset.Set // Nodes on the path from the start
+	backward  *bitset.Set // Nodes on the path to the end
+	head      int         // Last node on the forward path
+	tail      int         // First node on the backward path
+	node      int         // Node added to get to this state, -1 at the root
+	depth     int
+	parent    *State
+	problem   *tsppd.Problem
+	verbosity uint
+	width     uint
+	dual      tsppd.InferenceDual
+}
+
+// CreateRootState makes the initial state for a bidirectional DD TSPPD solver.
+func CreateRootState(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) *State {
+	forward := bitset.New(len(problem.Nodes))
+	forward.Add(problem.StartIndex())
+
+	backward := bitset.New(len(problem.Nodes))
+	backward.Add(problem.EndIndex())
+
+	// Without pickups and deliveries, the root joins the start to the end.
+	var cost int64
+	if len(problem.Nodes) == 2 {
+		cost = problem.CostIndex(problem.StartIndex(), problem.EndIndex())
+	}
+
+	return &State{
+		cost:      cost,
+		forward:   forward,
+		backward:  backward,
+		head:      problem.StartIndex(),
+		tail:      problem.EndIndex(),
+		node:      -1,
+		depth:     0,
+		parent:    nil,
+		problem:   problem,
+		verbosity: verbosity,
+		width:     width,
+		dual:      inference.CreateInferenceDual(problem, infer),
+	}
+}
+
+// Cost returns the cost of the forward and backward paths represented by a State.
+func (s *State) Cost() int64 {
+	return s.cost
+}
+
+// IsSolved returns true if this state is a final solution.
+func (s *State) IsSolved() bool {
+	return s.forward.Len()+s.backward.Len() == len(s.problem.Nodes)
+}
+
+// Next creates the next feasible states accessible from a State. Even
+// depths extend the forward path and odd depths extend the backward path.
+func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
+	states := make([]ddo.State, 0, len(s.problem.Nodes)/2)
+	if s.IsSolved() {
+		return states
+	}
+
+	isForward := s.depth%2 == 0
+	remaining := len(s.problem.Nodes) - s.forward.Len() - s.backward.Len()
+
+	for next := range s.problem.Nodes {
+		var index1, index2 int
+		if isForward {
+			if !s.isForwardFeasible(next) {
+				continue
+			}
+			index1, index2 = s.head, next
+		} else {
+			if !s.isBackwardFeasible(next) {
+				continue
+			}
+			index1, index2 = next, s.tail
+		}
+
+		// Arcs may have been eliminated by preprocessing.
+		if s.problem.IsRemovedArc(index1, index2) {
+			continue
+		}
+
+		// Don't generate solutions that are worse than the current incumbent.
+		cost := s.Cost() + s.problem.CostIndex(index1, index2)
+		if remaining == 1 {
+			// The last node joins the forward and backward paths.
+			join1, join2 := s.head, next
+			if isForward {
+				join1, join2 = next, s.tail
+			}
+			if s.problem.IsRemovedArc(join1, join2) {
+				continue
+			}
+			cost += s.problem.CostIndex(join1, join2)
+		}
+		if incumbent != nil && cost >= incumbent.Cost() {
+			continue
+		}
+
+		// Reduced cost-based domain filtering.
+		if inferenceDual != nil && inferenceDual.(tsppd.InferenceDual).FilterIndex(index1, index2, incumbent) {
+			continue
+		}
+
+		states = append(states, s.extend(next, cost))
+	}
+
+	s.printStates(states)
+	return states
+}
+
+// CreateSolutionState converts a complete solution into a State by
+// alternately extending the forward path from the front of its path and
+// the backward path from the back.
+func CreateSolutionState(root *State, solution *tsppd.Solution) *State {
+	s := root
+	front, back := 1, len(solution.Path)-2
+	for front <= back {
+		isForward := s.depth%2 == 0
+
+		var next int
+		cost := s.cost
+		if isForward {
+			next, _ = s.problem.Index(solution.Path[front])
+			cost += s.problem.CostIndex(s.head, next)
+			front++
+		} else {
+			next, _ = s.problem.Index(solution.Path[back])
+			cost += s.problem.CostIndex(next, s.tail)
+			back--
+		}
+
+		// The last node joins the forward and backward paths.
+		if front > back {
+			if isForward {
+				cost += s.problem.CostIndex(next, s.tail)
+			} else {
+				cost += s.problem.CostIndex(s.head, next)
+			}
+		}
+
+		s = s.extend(next, cost)
+	}
+	return s
+}
+
+// Solution returns the full or partial solution of a bidirectional TSPPD
+// State. A partial solution lists the forward path then the backward path.
+func (s *State) Solution() *tsppd.Solution {
+	rforward := []string{}
+	backward := []string{}
+	for state := s; state.parent != nil; state = state.parent {
+		if state.isForward() {
+			rforward = append(rforward, s.problem.Nodes[state.node])
+		} else {
+			backward = append(backward, s.problem.Nodes[state.node])
+		}
+	}
+
+	path := []string{s.problem.Start}
+	for i := len(rforward) - 1; i >= 0; i-- {
+		path = append(path, rforward[i])
+	}
+	path = append(path, backward...)
+	path = append(path, s.problem.End)
+
+	return &tsppd.Solution{
+		Path:    path,
+		Problem: s.problem,
+	}
+}
+
+// Infer creates an inference diagram.
+func (s *State) Infer() *ddo.Diagram {
+	if s.dual == nil {
+		return nil
+	}
+
+	if s.parent != nil {
+		if s.isForward() {
+			s.dual = s.dual.Branch(s.parent.head, s.node, nil, nil)
+		} else {
+			s.dual = s.dual.Branch(s.node, s.parent.tail, nil, nil)
+		}
+		if s.IsSolved() {
+			s.dual = s.dual.Branch(s.head, s.tail, nil, nil)
+		}
+	}
+	return ddo.CreateDiagram(s.dual, []ddo.Merger{}, s.width)
+}
+
+// Relax returns nil, as the bidirectional formulation has no relaxation.
+func (s *State) Relax() *ddo.Diagram {
+	return nil
+}
+
+// Restrict creates a restriction diagram.
+func (s *State) Restrict() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{ddo.MaxCostRestrictionMerger}, s.width)
+}
+
+// extend creates a new State by adding a node to the forward path at even
+// depths and to the backward path at odd depths.
+func (s *State) extend(next int, cost int64) *State {
+	state := &State{
+		cost:      cost,
+		forward:   s.forward,
+		backward:  s.backward,
+		head:      s.head,
+		tail:      s.tail,
+		node:      next,
+		depth:     s.depth + 1,
+		parent:    s,
+		problem:   s.problem,
+		verbosity: s.verbosity,
+		width:     s.width,
+		dual:      s.dual,
+	}
+
+	if s.depth%2 == 0 {
+		state.forward = s.forward.Copy()
+		state.forward.Add(next)
+		state.head = next
+	} else {
+		state.backward = s.backward.Copy()
+		state.backward.Add(next)
+		state.tail = next
+	}
+	return state
+}
+
+// isForwardFeasible returns true if a node can be appended to the forward
+// path: pickups can always be added, and deliveries once their pickup is.
+func (s *State) isForwardFeasible(next int) bool {
+	if s.forward.Contains(next) || s.backward.Contains(next) {
+		return false
+	}
+	if s.problem.IsDeliveryIndex(next) {
+		return s.forward.Contains(s.problem.PairIndex(next))
+	}
+	return s.problem.IsPickupIndex(next)
+}
+
+// isBackwardFeasible returns true if a node can be prepended to the
+// backward path: deliveries can always be added, and pickups once their
+// delivery is.
+func (s *State) isBackwardFeasible(next int) bool {
+	if s.forward.Contains(next) || s.backward.Contains(next) {
+		return false
+	}
+	if s.problem.IsPickupIndex(next) {
+		return s.backward.Contains(s.problem.PairIndex(next))
+	}
+	return s.problem.IsDeliveryIndex(next)
+}
+
+// isForward returns true if a State was created by extending the forward path.
+func (s *State) isForward() bool {
+	return s.depth%2 == 1
+}
@@ -0,0 +1,60 @@
+package solvers_test
+
+import (
+	"testing"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// maxFuzzNodes keeps fuzzed solves fast.
+const maxFuzzNodes = 12
+
+var fuzzProblems = []string{
+	`{"Nodes": ["+0", "-0", "+1", "-1"], "Precedence": {"+1": "-1"}, "Edges": [[0, 1, 2, 3], [1, 0, 2, 3], [2, 2, 0, 1], [3, 3, 1, 0]]}`,
+	`{"Nodes": ["+0", "-0", "+1", "-1", "+2", "-2"], "Precedence": {"+1": "-1", "+2": "-2"}, "Coordinates": {"+0": [0, 0], "-0": [0, 0], "+1": [3, 4], "-1": [6, 8], "+2": [1, 1], "-2": [5, 2]}, "Metric": "euclidean"}`,
+	`{"Nodes": ["depot", "home", "a", "b"], "Start": "depot", "End": "home", "Precedence": {"a": "b"}, "Coordinates": {"depot": [40.7, -74.0], "home": [40.7, -74.0], "a": [40.8, -73.9], "b": [40.6, -74.1]}, "Metric": "haversine"}`,
+	`{"Nodes": ["+0", "-0", "+1", "-1"], "Precedence": {"+1": "-1"}, "Edges": [[0, 1], [1, 0, 2, 3]]}`,
+	`{"Nodes": ["+0", "-0", "+1"], "Precedence": {"+1": "-7"}}`,
+	`{"Nodes": ["+0", "-0"], "Precedence": {}, "Edges": [[0, 1], [1, 0]]}`,
+	`{"Nodes": ["+0", "-0", "+1", "-1"], "Precedence": {"+1": "-1"}, "Edges": [[0, -5, 2, 3], [1, 0, 2, 3], [2, 2, 0, -9], [3, 3, 1, 0]]}`,
+	`{"Nodes": ["+0", "-0", "+1", "-1"], "Precedence": {"+1": "-1"}, "Edges": [[0, 1, 9223372036854775807, 3], [1, 0, 2, 3], [2, 2, 0, 9223372036854775807], [3, 3, 1, 0]]}`,
+	`{"Nodes": [`,
+}
+
+// FuzzDecode decodes and validates arbitrary input. Problems that pass
+// validation must be solvable by every formulation without panicking, and
+// any solution found must be valid.
+func FuzzDecode(f *testing.F) {
+	for _, problem := range fuzzProblems {
+		f.Add([]byte(problem))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		decoded, err := tsppd.Decode(b)
+		if err != nil || decoded.Validate() != nil || len(decoded.Nodes) > maxFuzzNodes {
+			return
+		}
+
+		for _, c := range []config{
+			{form: "sequential", infer: "ap+arb", relax: "dd", ordering: "regret", width: 2, workers: 1},
+			{form: "successor", infer: "ap", relax: "none", ordering: "ap-spread", width: 2, workers: 1},
+			{form: "bidirectional", infer: "lagrangian", relax: "none", width: 2, workers: 1},
+		} {
+			problem, _ := tsppd.Decode(b)
+
+			solver := ddo.CreateSolver(createRootState(&problem, c), func(*ddo.Bounds, ddo.Statistics) {})
+			solver.Workers = c.workers
+			solver.MaxNodes = 20
+			incumbent := solver.Minimize()
+			if incumbent == nil {
+				continue
+			}
+
+			solution := incumbent.(tsppd.State).Solution()
+			if err := solution.Validate(); err != nil {
+				t.Errorf("%v: invalid path %v: %v", c, solution.Path, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package inference
+
+import (
+	"strings"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+
+	// Built in inference duals register themselves.
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/arbdual"
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/lagdual"
+)
+
+// IsValid returns true if name is "none", a registered inference dual, or
+// several inference duals joined by "+", such as "ap+arb".
+func IsValid(name string) bool {
+	if name == "none" {
+		return true
+	}
+	for _, n := range strings.Split(name, "+") {
+		if _, err := registry.LookupInferenceDual(n); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateInferenceDual creates the root inference dual for a TSPPD instance
+// by name, or returns nil if name is "none" or unknown. Names joined by "+"
+// create a dual that takes the max of their bounds.
+func CreateInferenceDual(problem *tsppd.Problem, name string) tsppd.InferenceDual {
+	names := strings.Split(name, "+")
+	if len(names) > 1 {
+		duals := make([]tsppd.InferenceDual, 0, len(names))
+		for _, n := range names {
+			if dual := CreateInferenceDual(problem, n); dual != nil {
+				duals = append(duals, dual)
+			}
+		}
+		return createMaxDual(duals)
+	}
+
+	if dual, err := registry.LookupInferenceDual(name); err == nil {
+		return dual.Create(problem)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package inference
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// maxDual combines inference duals by taking the max of their bounds.
+// An arc is filtered if any of them filters it.
+type maxDual struct {
+	duals []tsppd.InferenceDual
+}
+
+func createMaxDual(duals []tsppd.InferenceDual) tsppd.InferenceDual {
+	if len(duals) == 0 {
+		return nil
+	}
+	return &maxDual{duals: duals}
+}
+
+// Branch branches on every combined dual.
+func (m *maxDual) Branch(index1, index2 int, from, to *bitset.Set) tsppd.InferenceDual {
+	duals := make([]tsppd.InferenceDual, len(m.duals))
+	for i, dual := range m.duals {
+		duals[i] = dual.Branch(index1, index2, from, to)
+	}
+	return &maxDual{duals: duals}
+}
+
+// Cost returns the max bound of the combined duals.
+func (m *maxDual) Cost() int64 {
+	return m.best().Cost()
+}
+
+// IsSolved will always be true.
+func (m *maxDual) IsSolved() bool {
+	return true
+}
+
+// Next advances every combined dual.
+func (m *maxDual) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
+	duals := make([]tsppd.InferenceDual, len(m.duals))
+	for i, dual := range m.duals {
+		duals[i] = dual.Next(dual, incumbent)[0].(tsppd.InferenceDual)
+	}
+	return []ddo.State{&maxDual{duals: duals}}
+}
+
+// Infer doesn't do much for combined duals.
+func (m *maxDual) Infer() *ddo.Diagram {
+	return ddo.CreateDiagram(m, []ddo.Merger{}, 0)
+}
+
+// Relax doesn't do much for combined duals.
+func (m *maxDual) Relax() *ddo.Diagram {
+	return ddo.CreateDiagram(m, []ddo.Merger{}, 0)
+}
+
+// Restrict doesn't do much for combined duals either.
+func (m *maxDual) Restrict() *ddo.Diagram {
+	return ddo.CreateDiagram(m, []ddo.Merger{}, 0)
+}
+
+// FilterIndex returns true if any combined dual filters an arc.
+func (m *maxDual) FilterIndex(index1, index2 int, incumbent ddo.State) bool {
+	for _, dual := range m.duals {
+		if dual.FilterIndex(index1, index2, incumbent) {
+			return true
+		}
+	}
+	return false
+}
+
+// RC returns the reduced cost of an arc in the dual with the max bound.
+func (m *maxDual) RC(index1, index2 int) int64 {
+	return m.best().RC(index1, index2)
+}
+
+func (m *maxDual) best() tsppd.InferenceDual {
+	best := m.duals[0]
+	for _, dual := range m.duals[1:] {
+		if dual.Cost() > best.Cost() {
+			best = dual
+		}
+	}
+	return best
+}
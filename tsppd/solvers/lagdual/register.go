@@ -0,0 +1,18 @@
+package lagdual
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+func init() {
+	registry.RegisterInferenceDual(registry.InferenceDual{
+		Component: registry.Component{
+			Name:        "lagrangian",
+			Description: "assignment problem with precedence and subtour cuts dualized",
+		},
+		Create: func(problem *tsppd.Problem) tsppd.InferenceDual {
+			return CreateLagrangianDualState(problem)
+		},
+	})
+}
@@ -0,0 +1,285 @@
+package lagdual
+
+import (
+	"math"
+
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+)
+
+const (
+	rootIterations   = 25  // Subgradient iterations for the root relaxation
+	branchIterations = 3   // Subgradient iterations after each branch
+	initialStep      = 2.0 // Initial subgradient step size multiplier
+	minStep          = 0.01
+)
+
+// A cut requires at least rhs arcs to leave a set of nodes in any tour
+// that is closed by the arc from the end node to the start node.
+type cut struct {
+	set *bitset.Set
+	rhs int64
+}
+
+// State represents a Lagrangian relaxation of the TSPPD on top of an AP.
+// Subtour elimination and precedence constraints are dualized as cuts with
+// nonnegative integer multipliers, which are updated by subgradient steps.
+// Each call to Next performs another step using the incumbent as a target.
+//
+// The penalized AP is warm started after each step and branch. A step only
+// reprices the rows of cuts whose multipliers changed, and a branch keeps
+// the penalized costs of its parent.
+type State struct {
+	root    *apdual.State // AP with original costs, shared by every State
+	ap      *apdual.State // AP with branching decisions and penalized costs
+	cuts    []cut
+	lambda  []int64 // lambda[k] = multiplier of cuts[k]
+	step    float64
+	bound   int64
+	problem *tsppd.Problem
+}
+
+// CreateLagrangianDualState creates a State that maintains a Lagrangian
+// relaxation of the precedence and subtour constraints.
+func CreateLagrangianDualState(problem *tsppd.Problem) *State {
+	root := apdual.CreateAPDualState(problem)
+	s := &State{
+		root:    root,
+		ap:      root,
+		step:    initialStep,
+		bound:   root.Cost(),
+		problem: problem,
+	}
+	return s.iterate(rootIterations, nil)
+}
+
+// Branch returns a new Lagrangian State with an edge forced on and the
+// edges from each index in from to each index in to forced off. It keeps
+// the current multipliers as a starting point.
+func (s *State) Branch(index1, index2 int, from, to *bitset.Set) tsppd.InferenceDual {
+	newState := &State{
+		root:    s.root,
+		ap:      s.ap.Branch(index1, index2, from, to).(*apdual.State),
+		cuts:    s.cuts,
+		lambda:  s.lambda,
+		step:    initialStep,
+		problem: s.problem,
+	}
+	newState.bound = newState.ap.Cost() + newState.constant()
+	return newState.iterate(branchIterations, nil)
+}
+
+// Cost returns the Lagrangian bound.
+func (s *State) Cost() int64 {
+	return s.bound
+}
+
+// IsSolved will always be true.
+func (s *State) IsSolved() bool {
+	return true
+}
+
+// Next takes another subgradient step toward the incumbent cost.
+func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
+	return []ddo.State{s.iterate(1, incumbent)}
+}
+
+// Infer doesn't do much for the Lagrangian relaxation.
+func (s *State) Infer() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// Relax doesn't do much for the Lagrangian relaxation.
+func (s *State) Relax() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// Restrict doesn't do much for the Lagrangian relaxation either.
+func (s *State) Restrict() *ddo.Diagram {
+	return ddo.CreateDiagram(s, []ddo.Merger{}, 0)
+}
+
+// FilterIndex returns true if the given edge can't be in an optimal solution.
+func (s *State) FilterIndex(index1, index2 int, incumbent ddo.State) bool {
+	if incumbent == nil {
+		return false
+	}
+	return s.bound+s.ap.RC(index1, index2) >= incumbent.Cost()
+}
+
+// RC returns the reduced cost of an edge in the penalized AP.
+func (s *State) RC(index1, index2 int) int64 {
+	return s.ap.RC(index1, index2)
+}
+
+// iterate performs subgradient steps, keeping only steps that improve the
+// bound and halving the step size after steps that don't.
+func (s *State) iterate(iterations int, incumbent ddo.State) *State {
+	current := s
+	for i := 0; i < iterations && current.step >= minStep; i++ {
+		next := current.subgradient(incumbent)
+		if next == nil {
+			break
+		}
+
+		if next.bound > current.bound {
+			current = next
+		} else {
+			halved := *current
+			halved.step /= 2
+			current = &halved
+		}
+	}
+	return current
+}
+
+// subgradient returns a new State after one subgradient step, or nil if
+// the AP solution violates no dualized constraints.
+func (s *State) subgradient(incumbent ddo.State) *State {
+	cuts := append(s.cuts[:len(s.cuts):len(s.cuts)], s.separate()...)
+	lambda := make([]int64, len(cuts))
+	copy(lambda, s.lambda)
+
+	g := make([]int64, len(cuts))
+	var norm int64
+	for k, c := range cuts {
+		g[k] = c.rhs - s.crossings(c.set)
+		if lambda[k] > 0 || g[k] > 0 {
+			norm += g[k] * g[k]
+		}
+	}
+	if norm == 0 {
+		return nil
+	}
+
+	// Polyak step toward the incumbent, or toward a nearby target without one.
+	target := s.bound + s.bound/20 + 1
+	if incumbent != nil && incumbent.Cost() > s.bound {
+		target = incumbent.Cost()
+	}
+	t := s.step * float64(target-s.bound) / float64(norm)
+
+	next := &State{
+		root:    s.root,
+		step:    s.step,
+		problem: s.problem,
+	}
+
+	// Only rows in cuts whose multipliers change need to be repriced.
+	changed := bitset.New(len(s.problem.Nodes))
+	for k, c := range cuts {
+		l := lambda[k] + int64(math.Round(t*float64(g[k])))
+		if l <= lambda[k] && g[k] > 0 {
+			l = lambda[k] + 1
+		}
+		if l < 0 {
+			l = 0
+		}
+		if l != lambda[k] {
+			changed.AddAll(c.set)
+		}
+		if l > 0 {
+			next.cuts = append(next.cuts, c)
+			next.lambda = append(next.lambda, l)
+		}
+	}
+
+	rows := map[int][]int64{}
+	for i := changed.Min(); i >= 0; i = changed.Next(i + 1) {
+		rows[i] = next.penalizedRow(i)
+	}
+	next.ap = s.ap.Reprice(rows)
+	next.bound = next.ap.Cost() + next.constant()
+	return next
+}
+
+// penalizedRow returns the original costs of arcs leaving index, less the
+// multipliers of each cut those arcs leave.
+func (s *State) penalizedRow(index int) []int64 {
+	n := len(s.problem.Nodes)
+	row := make([]int64, n)
+	for j := 0; j < n; j++ {
+		row[j] = s.root.ArcCost(index, j)
+	}
+	for k, c := range s.cuts {
+		if !c.set.Contains(index) {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			if !c.set.Contains(j) {
+				row[j] -= s.lambda[k]
+			}
+		}
+	}
+	return row
+}
+
+// constant returns the part of the Lagrangian bound that doesn't depend on
+// the AP solution.
+func (s *State) constant() int64 {
+	var constant int64
+	for k, c := range s.cuts {
+		constant += s.lambda[k] * c.rhs
+	}
+	return constant
+}
+
+// separate finds cuts violated by the AP solution. Each subtour that
+// doesn't contain the start node must be left at least once. If a delivery
+// precedes its pickup on the tour through the start node, the nodes from the
+// start through that delivery must be left at least twice.
+func (s *State) separate() []cut {
+	n := len(s.problem.Nodes)
+	start := s.problem.StartIndex()
+	visited := make([]bool, n)
+	cuts := []cut{}
+
+	// Tour containing the start node.
+	prefix := bitset.New(n)
+	for i := start; !visited[i]; i = s.ap.Successor(i) {
+		visited[i] = true
+		prefix.Add(i)
+
+		if s.problem.IsDeliveryIndex(i) && !prefix.Contains(s.problem.PairIndex(i)) {
+			cuts = s.addCut(cuts, cut{prefix.Copy(), 2})
+		}
+	}
+
+	// Subtours.
+	for i := 0; i < n; i++ {
+		if visited[i] {
+			continue
+		}
+		subtour := bitset.New(n)
+		for j := i; !visited[j]; j = s.ap.Successor(j) {
+			visited[j] = true
+			subtour.Add(j)
+		}
+		cuts = s.addCut(cuts, cut{subtour, 1})
+	}
+
+	return cuts
+}
+
+// addCut appends c to cuts unless it is already in the cut pool.
+func (s *State) addCut(cuts []cut, c cut) []cut {
+	for _, existing := range s.cuts {
+		if existing.rhs == c.rhs && existing.set.Equal(c.set) {
+			return cuts
+		}
+	}
+	return append(cuts, c)
+}
+
+// crossings returns the number of AP arcs leaving a set of nodes.
+func (s *State) crossings(set *bitset.Set) int64 {
+	var count int64
+	for i := set.Min(); i >= 0; i = set.Next(i + 1) {
+		if !set.Contains(s.ap.Successor(i)) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,60 @@
+package sequential
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// A fixedOrder restricts search to paths that visit every node that isn't
+// free in the same relative order as a reference solution. Free nodes can
+// be visited anywhere precedence allows.
+type fixedOrder struct {
+	order []int       // Fixed nodes after the start, in order
+	free  *bitset.Set // Nodes that can be visited in any order
+}
+
+// CreateFixedOrderState makes a root state like the given one, but only
+// searches paths that keep the nodes of a solution that aren't free in
+// their original order. Relaxation diagrams are not used, as merged states
+// can disagree on their position in the fixed order.
+func CreateFixedOrderState(root *State, solution *tsppd.Solution, free *bitset.Set) *State {
+	order := []int{}
+	for _, node := range solution.Path[1:] {
+		index, _ := root.problem.Index(node)
+		if !free.Contains(index) {
+			order = append(order, index)
+		}
+	}
+
+	return &State{
+		cost:      root.cost,
+		feasible:  root.feasible,
+		node:      root.node,
+		parent:    root.parent,
+		problem:   root.problem,
+		verbosity: root.verbosity,
+		width:     root.width,
+		dual:      root.dual,
+		relax:     nil,
+		order:     root.order,
+		fixed:     &fixedOrder{order: order, free: free},
+		fixedNext: 0,
+	}
+}
+
+// isFixedFeasible returns true if the next node is free or is the next
+// node in the fixed order.
+func (s *State) isFixedFeasible(next int) bool {
+	if s.fixed == nil || s.fixed.free.Contains(next) {
+		return true
+	}
+	return s.fixedNext < len(s.fixed.order) && s.fixed.order[s.fixedNext] == next
+}
+
+// nextFixed returns the position in the fixed order after visiting next.
+func (s *State) nextFixed(next int) int {
+	if s.fixed == nil || s.fixed.free.Contains(next) {
+		return s.fixedNext
+	}
+	return s.fixedNext + 1
+}
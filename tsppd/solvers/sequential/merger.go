@@ -3,38 +3,49 @@ package sequential
 import (
 	"sort"
 
+	"github.com/ryanjoneil/tsppd-dd/bitset"
 	"github.com/ryanjoneil/tsppd-dd/ddo"
 )
 
-// MaxCostRelaxationMerger combines the top states by cost.
+// MaxCostRelaxationMerger combines the top states by cost. The merged
+// state keeps the lowest cost, the union of feasible next nodes, and every
+// last node, so that no path through the merged states costs less.
 func MaxCostRelaxationMerger(states []ddo.State, width uint) []ddo.State {
 	sort.Sort(ddo.ByCost(states))
 
-	feasible := map[string]bool{}
+	lastState := states[width-1].(*State)
+	mergedFeasible := lastState.feasible.Copy()
+	mergedNodes := bitset.New(len(lastState.problem.Nodes))
 	for _, state := range states[width-1:] {
 		s := state.(*State)
-		for _, next := range s.feasible {
-			feasible[next] = true
+		mergedFeasible.AddAll(s.feasible)
+		if s.merged != nil {
+			mergedNodes.AddAll(s.merged)
+		} else {
+			mergedNodes.Add(s.node)
 		}
 	}
 
-	mergedFeasible := []string{}
-	for next := range feasible {
-		mergedFeasible = append(mergedFeasible, next)
-	}
-
 	mergedStates := []ddo.State{}
 	for _, state := range states[:width-1] {
 		mergedStates = append(mergedStates, state)
 	}
 
-	lastState := states[width-1].(*State)
 	mergedStates = append(mergedStates, &State{
-		cost:     lastState.cost,
-		feasible: mergedFeasible,
-		node:     lastState.node,
-		parent:   lastState.parent,
-		problem:  lastState.problem,
+		cost:      lastState.cost,
+		feasible:  mergedFeasible,
+		node:      lastState.node,
+		merged:    mergedNodes,
+		superset:  true,
+		parent:    lastState.parent,
+		problem:   lastState.problem,
+		verbosity: lastState.verbosity,
+		width:     lastState.width,
+		dual:      lastState.dual,
+		relax:     lastState.relax,
+		order:     lastState.order,
+		fixed:     lastState.fixed,
+		fixedNext: lastState.fixedNext,
 	})
 
 	return mergedStates
@@ -0,0 +1,87 @@
+package sequential
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// An Orderer sorts the candidate next nodes of a State so that the most
+// promising ones are expanded first. Orderings registered for the
+// sequential formulation create an Orderer, or nil to keep input order.
+type Orderer func(s *State, candidates []int, inferenceDual ddo.State)
+
+// orderNearest expands the cheapest arcs from the current node first.
+func (s *State) orderNearest(candidates []int, inferenceDual ddo.State) {
+	s.orderBy(candidates, func(next int) int64 {
+		return s.problem.CostIndex(s.node, next)
+	})
+}
+
+// orderAPReducedCost expands arcs with the smallest reduced cost first.
+func (s *State) orderAPReducedCost(candidates []int, inferenceDual ddo.State) {
+	dual := s.dual
+	if inferenceDual != nil {
+		dual = inferenceDual.(tsppd.InferenceDual)
+	}
+	if dual == nil {
+		return
+	}
+
+	s.orderBy(candidates, func(next int) int64 {
+		return dual.RC(s.node, next)
+	})
+}
+
+// orderRegret expands nodes that would be most expensive to reach from any
+// other candidate later first.
+func (s *State) orderRegret(candidates []int, inferenceDual ddo.State) {
+	s.orderBy(candidates, func(next int) int64 {
+		var minCost int64 = math.MaxInt64
+		for _, other := range candidates {
+			if other != next && s.problem.IsFeasibleIndex(other, next) {
+				if c := s.problem.CostIndex(other, next); c < minCost {
+					minCost = c
+				}
+			}
+		}
+		if minCost == math.MaxInt64 {
+			return math.MinInt64
+		}
+		return s.problem.CostIndex(s.node, next) - minCost
+	})
+}
+
+// orderBy stably sorts candidates by increasing key.
+func (s *State) orderBy(candidates []int, key func(next int) int64) {
+	ics := make([]indexCost, len(candidates))
+	for i, next := range candidates {
+		ics[i] = indexCost{next, key(next)}
+	}
+
+	sort.Stable(byIndexCost(ics))
+	for i, ic := range ics {
+		candidates[i] = ic.index
+	}
+}
+
+type indexCost struct {
+	index int
+	cost  int64
+}
+
+type byIndexCost []indexCost
+
+func (b byIndexCost) Len() int {
+	return len(b)
+}
+
+func (b byIndexCost) Less(i, j int) bool {
+	return b[i].cost < b[j].cost
+}
+
+func (b byIndexCost) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
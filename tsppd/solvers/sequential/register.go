@@ -0,0 +1,57 @@
+package sequential
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+const name = "sequential"
+
+func init() {
+	registry.RegisterFormulation(registry.Formulation{
+		Component: registry.Component{
+			Name:        name,
+			Description: "builds routes forward from the start node",
+		},
+		Relaxations: []registry.Relaxation{
+			{
+				Component: registry.Component{Name: "dd", Description: "relaxed decision diagram that merges the costliest states"},
+				Create: func(problem *tsppd.Problem) []ddo.Merger {
+					return []ddo.Merger{MaxCostRelaxationMerger}
+				},
+			},
+		},
+		Orderings: []registry.Ordering{
+			{
+				Component: registry.Component{Name: "input", Description: "next nodes in input order (default)"},
+				Create:    orderer(nil), // Candidates are already in input order
+			},
+			{
+				Component: registry.Component{Name: "nearest", Description: "cheapest arcs first"},
+				Create:    orderer((*State).orderNearest),
+			},
+			{
+				Component:         registry.Component{Name: "ap-rc", Description: "smallest AP reduced costs first"},
+				RequiresInference: true,
+				Create:            orderer((*State).orderAPReducedCost),
+			},
+			{
+				Component: registry.Component{Name: "regret", Description: "largest regret over the next cheapest arc first"},
+				Create:    orderer((*State).orderRegret),
+			},
+		},
+		OrderingOptional: true,
+		CreateRootState: func(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) ddo.State {
+			return CreateRootState(problem, infer, relax, ordering, width, verbosity)
+		},
+		CreateSolutionState: func(root ddo.State, solution *tsppd.Solution) ddo.State {
+			return CreateSolutionState(root.(*State), solution)
+		},
+	})
+}
+
+// orderer returns an ordering constructor that always creates o.
+func orderer(o Orderer) func(*tsppd.Problem) interface{} {
+	return func(*tsppd.Problem) interface{} { return o }
+}
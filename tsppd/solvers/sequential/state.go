@@ -1,49 +1,64 @@
 package sequential
 
 import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
 	"github.com/ryanjoneil/tsppd-dd/ddo"
 	"github.com/ryanjoneil/tsppd-dd/tsppd"
-	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/inference"
 )
 
 // State represents a current feasible path order.
 type State struct {
 	cost      int64
-	feasible  []string
-	node      string
+	feasible  *bitset.Set // Indices of nodes that can be visited next
+	node      int         // Index of the last node in the path
+	merged    *bitset.Set // Last nodes of all states merged into a relaxed state, or nil
+	superset  bool        // Feasible may hold visited nodes, due to a merge
 	parent    *State
 	problem   *tsppd.Problem
 	verbosity uint
 	width     uint
-	ap        *apdual.State
-	relax     bool
+	dual      tsppd.InferenceDual
+	relax     []ddo.Merger // Mergers for relaxation diagrams, if any
+	order     Orderer
+	fixed     *fixedOrder // Optional restriction to a fixed order, for LNS
+	fixedNext int         // Position of the next fixed node to visit
 }
 
 // CreateRootState makes the initial state for a sequential DD TSPPD solver.
 func CreateRootState(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) *State {
-	feasible := []string{}
-	for _, n := range problem.Nodes {
-		if problem.IsPickup(n) {
-			feasible = append(feasible, n)
+	feasible := bitset.New(len(problem.Nodes))
+	for index := range problem.Nodes {
+		if problem.IsPickupIndex(index) {
+			feasible.Add(index)
 		}
 	}
 
-	var ap *apdual.State
-	if infer == "ap" {
-		ap = apdual.CreateAPDualState(problem)
+	// Without pickups, the path goes straight to the end.
+	if feasible.IsEmpty() {
+		feasible.Add(problem.EndIndex())
 	}
 
 	state := &State{
 		cost:      0,
 		feasible:  feasible,
-		node:      "+0",
+		node:      problem.StartIndex(),
 		parent:    nil,
 		problem:   problem,
 		verbosity: verbosity,
 		width:     width,
-		ap:        ap,
-		relax:     relax == "dd",
+		dual:      inference.CreateInferenceDual(problem, infer),
 	}
+
+	formulation, _ := registry.LookupFormulation(name)
+	if r, ok := formulation.Relaxation(relax); ok {
+		state.relax = r.Create(problem)
+	}
+	if o, ok := formulation.Ordering(ordering); ok {
+		state.order = o.Create(problem).(Orderer)
+	}
+
 	return state
 }
 
@@ -54,48 +69,90 @@ func (s *State) Cost() int64 {
 
 // IsSolved returns true if this state is a final solution.
 func (s *State) IsSolved() bool {
-	return len(s.feasible) == 0
+	return s.feasible.IsEmpty()
 }
 
 // Next creates the next feasible states accessible from a State.
 func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
 	states := make([]ddo.State, 0, len(s.problem.Nodes)/2)
 
-	for _, next := range s.feasible {
-		// Don't generate solutions that are worse than the current incumbent.
-		arcCost, _ := s.problem.Cost(s.node, next)
-		cost := s.Cost() + arcCost
-		if incumbent != nil && cost >= incumbent.Cost() {
+	candidates := s.feasible.Elements()
+	if s.order != nil {
+		s.order(s, candidates, inferenceDual)
+	}
+
+	for _, next := range candidates {
+		// Fixed nodes have to be visited in their fixed order.
+		if !s.isFixedFeasible(next) {
 			continue
 		}
 
-		// AP reduced cost-based domain filtering.
-		if inferenceDual != nil && inferenceDual.(*apdual.State).Filter(s.node, next, incumbent) {
+		if s.merged == nil {
+			if cost, ok := s.arcCost(s.node, next, inferenceDual, incumbent); ok {
+				states = append(states, s.extend(next, cost))
+			}
 			continue
 		}
 
-		states = append(states, &State{
-			cost:      cost,
-			feasible:  s.nextFeasible(next),
-			node:      next,
-			parent:    s,
-			problem:   s.problem,
-			verbosity: s.verbosity,
-			width:     s.width,
-			ap:        s.ap,
-			relax:     s.relax,
-		})
+		// A relaxed state can reach next from any of its merged nodes, so
+		// it takes the cheapest arc that isn't filtered.
+		best, found := int64(0), false
+		for node := s.merged.Min(); node >= 0; node = s.merged.Next(node + 1) {
+			if node == next {
+				continue
+			}
+			if cost, ok := s.arcCost(node, next, inferenceDual, incumbent); ok && (!found || cost < best) {
+				best, found = cost, true
+			}
+		}
+		if found {
+			states = append(states, s.extend(next, best))
+		}
 	}
 
 	s.printStates(states)
 	return states
 }
 
+// arcCost returns the cost of a path that follows this state with an arc
+// from node to next. It returns false if the arc can't be in a solution
+// better than the incumbent.
+func (s *State) arcCost(node, next int, inferenceDual ddo.State, incumbent ddo.State) (int64, bool) {
+	// Arcs may have been eliminated by preprocessing.
+	if s.problem.IsRemovedArc(node, next) {
+		return 0, false
+	}
+
+	// Don't generate solutions that are worse than the current incumbent.
+	cost := s.Cost() + s.problem.CostIndex(node, next)
+	if incumbent != nil && cost >= incumbent.Cost() {
+		return 0, false
+	}
+
+	// Reduced cost-based domain filtering.
+	if inferenceDual != nil && inferenceDual.(tsppd.InferenceDual).FilterIndex(node, next, incumbent) {
+		return 0, false
+	}
+
+	return cost, true
+}
+
+// CreateSolutionState converts a complete solution into a State by
+// extending the root state along its path.
+func CreateSolutionState(root *State, solution *tsppd.Solution) *State {
+	s := root
+	for _, node := range solution.Path[1:] {
+		next, _ := s.problem.Index(node)
+		s = s.extend(next, s.cost+s.problem.CostIndex(s.node, next))
+	}
+	return s
+}
+
 // Solution returns the full or partial solution of a sequential TSPPD State.
 func (s *State) Solution() *tsppd.Solution {
 	rpath := []string{}
 	for state := s; state != nil; state = state.parent {
-		rpath = append(rpath, state.node)
+		rpath = append(rpath, s.problem.Nodes[state.node])
 	}
 
 	path := []string{}
@@ -111,20 +168,19 @@ func (s *State) Solution() *tsppd.Solution {
 
 // Infer creates an inference diagram.
 func (s *State) Infer() *ddo.Diagram {
-	if s.ap != nil {
-		// AP Relaxation
+	if s.dual != nil {
 		if s.parent != nil {
-			s.ap = s.ap.Set(s.parent.node, s.node)
+			s.dual = s.dual.Branch(s.parent.node, s.node, nil, nil)
 		}
-		return ddo.CreateDiagram(s.ap, []ddo.Merger{}, s.width)
+		return ddo.CreateDiagram(s.dual, []ddo.Merger{}, s.width)
 	}
 	return nil
 }
 
 // Relax creates a relaxation diagram.
 func (s *State) Relax() *ddo.Diagram {
-	if s.relax {
-		return ddo.CreateDiagram(s, []ddo.Merger{MaxCostRelaxationMerger}, s.width)
+	if len(s.relax) > 0 {
+		return ddo.CreateDiagram(s, s.relax, s.width)
 	}
 	return nil
 }
@@ -136,26 +192,49 @@ func (s *State) Restrict() *ddo.Diagram {
 
 // Node returns the last node in the route.
 func (s *State) Node() string {
-	return s.node
+	return s.problem.Nodes[s.node]
 }
 
-func (s *State) nextFeasible(next string) []string {
-	if len(s.feasible) == 1 && s.problem.IsDelivery(s.feasible[0]) {
-		return []string{"-0"}
+func (s *State) extend(next int, cost int64) *State {
+	return &State{
+		cost:      cost,
+		feasible:  s.nextFeasible(next),
+		node:      next,
+		superset:  s.superset,
+		parent:    s,
+		problem:   s.problem,
+		verbosity: s.verbosity,
+		width:     s.width,
+		dual:      s.dual,
+		relax:     s.relax,
+		order:     s.order,
+		fixed:     s.fixed,
+		fixedNext: s.nextFixed(next),
 	}
+}
 
-	// New feasible set = current - next node + delivery.
-	feasible := make([]string, 0, len(s.feasible))
-
-	for _, node := range s.feasible {
-		if node != next {
-			feasible = append(feasible, node)
+func (s *State) nextFeasible(next int) *bitset.Set {
+	if s.feasible.Len() == 1 && s.problem.IsDeliveryIndex(s.feasible.Min()) || next == s.problem.EndIndex() {
+		feasible := bitset.New(len(s.problem.Nodes))
+		if next != s.problem.EndIndex() {
+			feasible.Add(s.problem.EndIndex())
 		}
+		return feasible
 	}
 
+	// New feasible set = current - next node + delivery.
+	feasible := s.feasible.Copy()
+	feasible.Remove(next)
+
 	// If next node is a pickup, add delivery.
-	if s.problem.IsPickup(next) {
-		feasible = append(feasible, s.problem.Precedence[next])
+	if s.problem.IsPickupIndex(next) {
+		feasible.Add(s.problem.PairIndex(next))
+	}
+
+	// After a merge, any delivery may be the last one, so the end has to
+	// stay reachable.
+	if s.superset && s.problem.IsDeliveryIndex(next) {
+		feasible.Add(s.problem.EndIndex())
 	}
 
 	return feasible
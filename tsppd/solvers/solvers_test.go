@@ -0,0 +1,224 @@
+package solvers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/generate"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/reference"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+
+	// Built in formulations register themselves.
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/bidirectional"
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/sequential"
+	_ "github.com/ryanjoneil/tsppd-dd/tsppd/solvers/successor"
+)
+
+type config struct {
+	form     string
+	infer    string
+	relax    string
+	ordering string
+	width    uint
+	workers  int
+}
+
+func (c config) String() string {
+	return fmt.Sprintf(
+		"form=%s,infer=%s,relax=%s,ordering=%s,width=%d,workers=%d",
+		c.form, c.infer, c.relax, c.ordering, c.width, c.workers,
+	)
+}
+
+var (
+	infers  = []string{"none", "ap", "arb", "lagrangian", "ap+arb"}
+	widths  = []uint{0, 1, 3}
+	workers = []int{1, 3}
+)
+
+// configs returns every valid combination of solver options, for every
+// registered formulation.
+func configs() []config {
+	all := []config{}
+	for _, formulation := range registry.Formulations() {
+		relaxes := []string{"none"}
+		for _, r := range formulation.Relaxations {
+			relaxes = append(relaxes, r.Name)
+		}
+		orderings := formulation.Orderings
+		if formulation.OrderingOptional {
+			orderings = append([]registry.Ordering{{}}, orderings...)
+		}
+
+		for _, infer := range infers {
+			for _, relax := range relaxes {
+				for _, ordering := range orderings {
+					if infer == "none" && ordering.RequiresInference {
+						continue
+					}
+					for _, width := range widths {
+						for _, w := range workers {
+							all = append(all, config{formulation.Name, infer, relax, ordering.Name, width, w})
+						}
+					}
+				}
+			}
+		}
+	}
+	return all
+}
+
+func createRootState(problem *tsppd.Problem, c config) ddo.State {
+	formulation, err := registry.LookupFormulation(c.form)
+	if err != nil {
+		panic(err)
+	}
+	return formulation.CreateRootState(problem, c.infer, c.relax, c.ordering, c.width, 0)
+}
+
+// TestSolversMatchReference solves random instances with every combination
+// of solver options, and checks each finds a valid path with the optimal
+// cost given by the reference solver.
+func TestSolversMatchReference(t *testing.T) {
+	instances := 6
+	if testing.Short() {
+		instances = 2
+	}
+
+	distributions := []string{"uniform", "clustered", "depot-centric"}
+	for i := 0; i < instances; i++ {
+		options := generate.Options{
+			Pairs:        2 + i%2,
+			Distribution: distributions[i%len(distributions)],
+			Metric:       "euclidean",
+			Asymmetry:    float64(i%3) * 0.25,
+			Seed:         int64(i),
+		}
+		checkSolvers(t, fmt.Sprintf("seed=%d", options.Seed), func() *tsppd.Problem {
+			problem, err := generate.Generate(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return problem
+		})
+	}
+}
+
+// largeCosts has arc costs in the billions, as haversine distances in
+// meters can be.
+const largeCosts = `{
+	"Nodes": ["+0", "-0", "+1", "-1", "+2", "-2"],
+	"Precedence": {"+1": "-1", "+2": "-2"},
+	"Edges": [
+		[0, 0, 1860000000, 8000000000, 2650000000, 1450000000],
+		[1000000000, 0, 1860000000, 8000000000, 2650000000, 1450000000],
+		[1860000000, 1860000000, 0, 9860000000, 3840000000, 1610000000],
+		[8000000000, 8000000000, 9860000000, 0, 7460000000, 8960000000],
+		[2650000000, 2650000000, 3840000000, 7460000000, 0, 2270000000],
+		[1450000000, 1450000000, 1610000000, 8960000000, 2270000000, 0]
+	]
+}`
+
+// TestSolversMatchReferenceOnEdgeCases checks instances that random
+// generation doesn't produce.
+func TestSolversMatchReferenceOnEdgeCases(t *testing.T) {
+	for name, instance := range map[string]string{
+		"no-pairs": `{"Nodes": ["+0", "-0"], "Precedence": {}, "Edges": [[0, 7], [3, 0]]}`,
+
+		// The arc from the end back to the start isn't free.
+		"separate-depots": `{
+			"Nodes": ["+0", "-0", "+1", "-1", "+2", "-2"],
+			"Precedence": {"+1": "-1", "+2": "-2"},
+			"Edges": [
+				[0, 0, 186, 800, 265, 145],
+				[100, 0, 186, 800, 265, 145],
+				[186, 186, 0, 986, 384, 161],
+				[800, 800, 986, 0, 746, 896],
+				[265, 265, 384, 746, 0, 227],
+				[145, 145, 161, 896, 227, 0]
+			]
+		}`,
+
+		"large-costs": largeCosts,
+	} {
+		instance := instance
+		checkSolvers(t, name, func() *tsppd.Problem {
+			problem, err := tsppd.Decode([]byte(instance))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := problem.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			return &problem
+		})
+	}
+}
+
+// TestAPAvoidsInfeasibleArcs checks that infeasible arcs cost more than
+// real ones in the root AP, which then has a solution without them.
+func TestAPAvoidsInfeasibleArcs(t *testing.T) {
+	problem, err := tsppd.Decode([]byte(largeCosts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := problem.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := apdual.CreateAPDualState(&problem)
+	for i := range problem.Nodes {
+		j := ap.Successor(i)
+		if !problem.IsFeasibleIndex(i, j) && !(i == problem.EndIndex() && j == problem.StartIndex()) {
+			t.Errorf("AP uses infeasible arc %s -> %s", problem.Nodes[i], problem.Nodes[j])
+		}
+	}
+}
+
+// checkSolvers solves a problem with every combination of solver options,
+// and checks each finds a valid path with the optimal cost given by the
+// reference solver. Each solve gets its own copy of the problem from
+// create, since preprocessing and search may change a problem.
+func checkSolvers(t *testing.T, name string, create func() *tsppd.Problem) {
+	reference, err := reference.Solve(create())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reference == nil {
+		t.Fatalf("%s: reference found no solution", name)
+	}
+	optimal, _ := reference.Cost()
+
+	for _, c := range configs() {
+		solver := ddo.CreateSolver(createRootState(create(), c), func(*ddo.Bounds, ddo.Statistics) {})
+		solver.Batch = 2
+		solver.Workers = c.workers
+		incumbent := solver.Minimize()
+		stats := solver.Statistics()
+
+		name := fmt.Sprintf("%s,%v", name, c)
+		if incumbent == nil {
+			t.Errorf("%s: no solution, expected cost %d", name, optimal)
+			continue
+		}
+
+		solution := incumbent.(tsppd.State).Solution()
+		if err := solution.Validate(); err != nil {
+			t.Errorf("%s: invalid path %v: %v", name, solution.Path, err)
+			continue
+		}
+		cost, _ := solution.Cost()
+		if cost != incumbent.Cost() {
+			t.Errorf("%s: path cost %d, search cost %d", name, cost, incumbent.Cost())
+		}
+		if cost != optimal {
+			t.Errorf("%s: cost %d, expected %d", name, cost, optimal)
+		}
+		if !stats.Optimal {
+			t.Errorf("%s: optimality not proven", name)
+		}
+	}
+}
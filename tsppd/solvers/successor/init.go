@@ -1,21 +1,23 @@
 package successor
 
+import "github.com/ryanjoneil/tsppd-dd/bitset"
+
 func (s *State) initDomain() {
 	s.domain = []int{}
-	for index, node := range s.problem.Nodes {
+	for index := range s.problem.Nodes {
 		// Domain includes everything that can be assigned to next.
-		if !s.problem.IsStart(node) {
+		if index != s.problem.StartIndex() {
 			s.domain = append(s.domain, index)
 		}
 	}
 }
 
 func (s *State) initPartial() {
-	s.partial = make([]*[]bool, 0, len(s.problem.Nodes))
+	s.partial = make([]*bitset.Set, 0, len(s.problem.Nodes))
 	for index := range s.problem.Nodes {
-		partial := make([]bool, len(s.problem.Nodes))
-		partial[index] = true
-		s.partial = append(s.partial, &partial)
+		partial := bitset.New(len(s.problem.Nodes))
+		partial.Add(index)
+		s.partial = append(s.partial, partial)
 	}
 }
 
@@ -29,43 +31,45 @@ func (s *State) initPrevNext() {
 }
 
 func (s *State) initPredSucc() {
-	s.pred = make([]*[]bool, 0, len(s.next))
-	s.succ = make([]*[]bool, 0, len(s.next))
+	s.pred = make([]*bitset.Set, 0, len(s.next))
+	s.succ = make([]*bitset.Set, 0, len(s.next))
+
+	start, end := s.problem.StartIndex(), s.problem.EndIndex()
 
-	for index1, node1 := range s.problem.Nodes {
-		pred := make([]bool, len(s.next))
-		succ := make([]bool, len(s.next))
+	for index1 := range s.problem.Nodes {
+		pred := bitset.New(len(s.next))
+		succ := bitset.New(len(s.next))
 
-		for index2, node2 := range s.problem.Nodes {
+		for index2 := range s.problem.Nodes {
 			if index2 == index1 {
 				continue
 			}
 
-			if s.problem.IsStart(node1) {
-				succ[index2] = true
+			if index1 == start {
+				succ.Add(index2)
 
-			} else if s.problem.IsPickup(node1) {
-				if s.problem.Precedes(node1, node2) || s.problem.IsEnd(node2) {
-					succ[index2] = true
+			} else if s.problem.IsPickupIndex(index1) {
+				if s.problem.PrecedesIndex(index1, index2) || index2 == end {
+					succ.Add(index2)
 				}
-				if s.problem.IsStart(node2) {
-					pred[index2] = true
+				if index2 == start {
+					pred.Add(index2)
 				}
 
-			} else if s.problem.IsDelivery(node1) {
-				if s.problem.Precedes(node2, node1) || s.problem.IsStart(node2) {
-					pred[index2] = true
+			} else if s.problem.IsDeliveryIndex(index1) {
+				if s.problem.PrecedesIndex(index2, index1) || index2 == start {
+					pred.Add(index2)
 				}
-				if s.problem.IsEnd(node2) {
-					succ[index2] = true
+				if index2 == end {
+					succ.Add(index2)
 				}
 
-			} else if s.problem.IsEnd(node1) {
-				pred[index2] = true
+			} else if index1 == end {
+				pred.Add(index2)
 			}
 		}
 
-		s.pred = append(s.pred, &pred)
-		s.succ = append(s.succ, &succ)
+		s.pred = append(s.pred, pred)
+		s.succ = append(s.succ, succ)
 	}
 }
@@ -1,5 +1,7 @@
 package successor
 
+import "github.com/ryanjoneil/tsppd-dd/bitset"
+
 func (s *State) feasible(index1 int) []int {
 	f := make([]int, 0, len(s.domain))
 
@@ -9,11 +11,16 @@ func (s *State) feasible(index1 int) []int {
 			continue
 		}
 
-		if intersect(s.partial[index1], s.succ[index2]) || intersect(s.pred[index1], s.partial[index2]) {
+		// Arcs may have been eliminated by preprocessing.
+		if s.problem.IsRemovedArc(index1, index2) {
+			continue
+		}
+
+		if s.partial[index1].Intersects(s.succ[index2]) || s.pred[index1].Intersects(s.partial[index2]) {
 			continue
 		}
 
-		if intersect(s.pred[index1], s.succ[index2]) || intersect(s.succ[index1], s.pred[index2]) {
+		if s.pred[index1].Intersects(s.succ[index2]) || s.succ[index1].Intersects(s.pred[index2]) {
 			continue
 		}
 
@@ -40,12 +47,12 @@ func (s *State) nextDomain(index2 int) []int {
 	return domain
 }
 
-func (s *State) nextPartial(index1, index2 int) []*[]bool {
-	u := union(s.partial[index1], s.partial[index2])
+func (s *State) nextPartial(index1, index2 int) []*bitset.Set {
+	u := s.partial[index1].Union(s.partial[index2])
 
-	partial := make([]*[]bool, len(s.partial))
+	partial := make([]*bitset.Set, len(s.partial))
 	for index := range s.partial {
-		if (*u)[index] {
+		if u.Contains(index) {
 			partial[index] = u
 		} else {
 			partial[index] = s.partial[index]
@@ -79,13 +86,13 @@ func (s *State) nextNext(index1, index2 int) []int {
 	return next
 }
 
-func (s *State) nextPred(index1, index2 int, partial *[]bool) []*[]bool {
+func (s *State) nextPred(index1, index2 int, partial *bitset.Set) []*bitset.Set {
 	oldPred1, oldPred2 := s.pred[index1], s.pred[index2]
-	u := unionMinus(oldPred1, oldPred2, partial)
+	u := oldPred1.UnionMinus(oldPred2, partial)
 
-	pred := make([]*[]bool, len(s.pred))
+	pred := make([]*bitset.Set, len(s.pred))
 	for index := range s.pred {
-		if (*partial)[index] {
+		if partial.Contains(index) {
 			pred[index] = u
 		} else {
 			pred[index] = s.pred[index]
@@ -95,13 +102,13 @@ func (s *State) nextPred(index1, index2 int, partial *[]bool) []*[]bool {
 	return pred
 }
 
-func (s *State) nextSucc(index1, index2 int, partial *[]bool) []*[]bool {
+func (s *State) nextSucc(index1, index2 int, partial *bitset.Set) []*bitset.Set {
 	oldSucc1, oldSucc2 := s.succ[index1], s.succ[index2]
-	u := unionMinus(oldSucc1, oldSucc2, partial)
+	u := oldSucc1.UnionMinus(oldSucc2, partial)
 
-	succ := make([]*[]bool, len(s.succ))
+	succ := make([]*bitset.Set, len(s.succ))
 	for index := range s.succ {
-		if (*partial)[index] {
+		if partial.Contains(index) {
 			succ[index] = u
 		} else {
 			succ[index] = s.succ[index]
@@ -112,66 +119,31 @@ func (s *State) nextSucc(index1, index2 int, partial *[]bool) []*[]bool {
 }
 
 func (s *State) inferPred(index int) {
-	inferredPred := union(s.pred[index], s.partial[index])
-
-	oldToNew := make([]*[]bool, len(s.succ))
-	for successor, v := range *s.succ[index] {
-		if !v {
-			continue
-		}
+	inferredPred := s.pred[index].Union(s.partial[index])
 
+	oldToNew := make([]*bitset.Set, len(s.succ))
+	succ := s.succ[index]
+	for successor := succ.Min(); successor >= 0; successor = succ.Next(successor + 1) {
 		if oldToNew[successor] != nil {
 			s.pred[successor] = oldToNew[successor]
 		} else {
-			oldToNew[successor] = unionMinus(inferredPred, s.pred[successor], s.partial[successor])
+			oldToNew[successor] = inferredPred.UnionMinus(s.pred[successor], s.partial[successor])
 			s.pred[successor] = oldToNew[successor]
 		}
 	}
 }
 
 func (s *State) inferSucc(index int) {
-	inferredSucc := union(s.succ[index], s.partial[index])
-
-	oldToNew := make([]*[]bool, len(s.succ))
-	for predecessor, v := range *s.pred[index] {
-		if !v {
-			continue
-		}
+	inferredSucc := s.succ[index].Union(s.partial[index])
 
+	oldToNew := make([]*bitset.Set, len(s.succ))
+	pred := s.pred[index]
+	for predecessor := pred.Min(); predecessor >= 0; predecessor = pred.Next(predecessor + 1) {
 		if oldToNew[predecessor] != nil {
 			s.succ[predecessor] = oldToNew[predecessor]
 		} else {
-			oldToNew[predecessor] = unionMinus(inferredSucc, s.succ[predecessor], s.partial[predecessor])
+			oldToNew[predecessor] = inferredSucc.UnionMinus(s.succ[predecessor], s.partial[predecessor])
 			s.succ[predecessor] = oldToNew[predecessor]
 		}
 	}
 }
-
-func intersect(set1, set2 *[]bool) bool {
-	for i := range *set1 {
-		if (*set1)[i] && (*set2)[i] {
-			return true
-		}
-	}
-	return false
-}
-
-func union(set1, set2 *[]bool) *[]bool {
-	u := make([]bool, len(*set1))
-	for i := range *set1 {
-		if (*set1)[i] || (*set2)[i] {
-			u[i] = true
-		}
-	}
-	return &u
-}
-
-func unionMinus(set1, set2, out *[]bool) *[]bool {
-	u := make([]bool, len(*set1))
-	for i := range *set1 {
-		if !(*out)[i] && ((*set1)[i] || (*set2)[i]) {
-			u[i] = true
-		}
-	}
-	return &u
-}
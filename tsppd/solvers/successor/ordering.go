@@ -1,32 +1,138 @@
 package successor
 
 import (
+	"fmt"
 	"math"
 	"sort"
+
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
 )
 
+// A StaticOrdering returns the order to assign variables in, fixed at the
+// root State.
+type StaticOrdering func(s *State) []int
+
+// A Selector chooses the next variable to assign from those unassigned at
+// a State, or returns -1 if there are none.
+type Selector func(s *State, inferenceDual ddo.State) int
+
+// initOrdering applies an ordering registered for the successor
+// formulation. Its constructor creates a StaticOrdering or a Selector.
 func (s *State) initOrdering(ordering string) {
 	s.ordering = []int{}
 
-	switch ordering {
-	case "input":
-		s.initOrderingInput()
-	case "greedy":
-		s.initOrderingGreedy()
-	case "regret":
-		s.initOrderingRegret()
+	s.unassigned = bitset.New(len(s.problem.Nodes))
+	for index := range s.problem.Nodes {
+		if index != s.problem.EndIndex() {
+			s.unassigned.Add(index)
+		}
+	}
+
+	formulation, _ := registry.LookupFormulation(name)
+	o, ok := formulation.Ordering(ordering)
+	if !ok {
+		return
+	}
+
+	switch order := o.Create(s.problem).(type) {
+	case StaticOrdering:
+		s.ordering = order(s)
+	case Selector:
+		s.selector = order
+	default:
+		panic(fmt.Sprintf("successor: ordering %s creates %T", ordering, order))
+	}
+}
+
+// variable returns the next variable to assign.
+func (s *State) variable(inferenceDual ddo.State) int {
+	if s.selector != nil {
+		return s.selector(s, inferenceDual)
+	}
+	if s.orderIdx >= len(s.ordering) {
+		return -1
+	}
+	return s.ordering[s.orderIdx]
+}
+
+// selectFailFirst chooses the variable with the smallest feasible domain.
+func (s *State) selectFailFirst(inferenceDual ddo.State) int {
+	best, bestSize := -1, math.MaxInt64
+	for index := s.unassigned.Min(); index >= 0; index = s.unassigned.Next(index + 1) {
+		if size := len(s.feasible(index)); size < bestSize {
+			best, bestSize = index, size
+		}
+	}
+	return best
+}
+
+// selectRegret chooses the variable with the largest difference between
+// the costs of its two cheapest feasible successors.
+func (s *State) selectRegret(inferenceDual ddo.State) int {
+	return s.selectMaxSpread(func(index1, index2 int) int64 {
+		return s.problem.CostIndex(index1, index2)
+	})
+}
+
+// selectAPSpread chooses the variable with the largest difference between
+// the reduced costs of its two cheapest feasible successors.
+func (s *State) selectAPSpread(inferenceDual ddo.State) int {
+	dual := s.dual
+	if inferenceDual != nil {
+		dual = inferenceDual.(tsppd.InferenceDual)
+	}
+	if dual == nil {
+		return s.selectRegret(inferenceDual)
 	}
+	return s.selectMaxSpread(dual.RC)
+}
+
+// selectMaxSpread chooses the variable with the largest spread between the
+// two smallest values of cost over its feasible successors. Variables with
+// at most one feasible successor are chosen immediately.
+func (s *State) selectMaxSpread(cost func(index1, index2 int) int64) int {
+	best := -1
+	var bestSpread int64 = -1
+
+	for index1 := s.unassigned.Min(); index1 >= 0; index1 = s.unassigned.Next(index1 + 1) {
+		feasible := s.feasible(index1)
+		if len(feasible) <= 1 {
+			return index1
+		}
+
+		var minCost1 int64 = math.MaxInt64
+		var minCost2 int64 = math.MaxInt64
+		for _, index2 := range feasible {
+			c := cost(index1, index2)
+			if c < minCost1 {
+				minCost1, minCost2 = c, minCost1
+			} else if c < minCost2 {
+				minCost2 = c
+			}
+		}
+
+		if spread := minCost2 - minCost1; spread > bestSpread {
+			best, bestSpread = index1, spread
+		}
+	}
+
+	return best
 }
 
-func (s *State) initOrderingInput() {
+func (s *State) orderingInput() []int {
+	ordering := []int{}
 	for index, node := range s.problem.Nodes {
 		if !s.problem.IsEnd(node) {
-			s.ordering = append(s.ordering, index)
+			ordering = append(ordering, index)
 		}
 	}
+	return ordering
 }
 
-func (s *State) initOrderingGreedy() {
+func (s *State) orderingGreedy() []int {
 	greedyIndexCosts := []indexCost{}
 
 	for index1, node1 := range s.problem.Nodes {
@@ -46,12 +152,14 @@ func (s *State) initOrderingGreedy() {
 	}
 
 	sort.Sort(byIndexCost(greedyIndexCosts))
+	ordering := []int{}
 	for _, gic := range greedyIndexCosts {
-		s.ordering = append(s.ordering, gic.index)
+		ordering = append(ordering, gic.index)
 	}
+	return ordering
 }
 
-func (s *State) initOrderingRegret() {
+func (s *State) orderingRegret() []int {
 	regretIndexCosts := []indexCost{}
 
 	for index1, node1 := range s.problem.Nodes {
@@ -74,9 +182,11 @@ func (s *State) initOrderingRegret() {
 	}
 
 	sort.Sort(sort.Reverse(byIndexCost(regretIndexCosts)))
+	ordering := []int{}
 	for _, gic := range regretIndexCosts {
-		s.ordering = append(s.ordering, gic.index)
+		ordering = append(ordering, gic.index)
 	}
+	return ordering
 }
 
 type indexCost struct {
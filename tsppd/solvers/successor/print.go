@@ -63,10 +63,8 @@ func (s *State) print() {
 		}
 		fmt.Printf("%p ", s.partial[index])
 		fmt.Printf("[%s] ", s.problem.Nodes[index])
-		for i, v := range *p {
-			if v {
-				fmt.Printf("%s ", s.problem.Nodes[i])
-			}
+		for _, i := range p.Elements() {
+			fmt.Printf("%s ", s.problem.Nodes[i])
 		}
 		fmt.Println()
 	}
@@ -79,10 +77,8 @@ func (s *State) print() {
 		}
 		fmt.Printf("%p ", s.pred[index])
 		fmt.Printf("[%s] ", s.problem.Nodes[index])
-		for i, v := range *p {
-			if v {
-				fmt.Printf("%s ", s.problem.Nodes[i])
-			}
+		for _, i := range p.Elements() {
+			fmt.Printf("%s ", s.problem.Nodes[i])
 		}
 		fmt.Println()
 	}
@@ -95,10 +91,8 @@ func (s *State) print() {
 		}
 		fmt.Printf("%p ", s.succ[index])
 		fmt.Printf("[%s] ", s.problem.Nodes[index])
-		for i, v := range *p {
-			if v {
-				fmt.Printf("%s ", s.problem.Nodes[i])
-			}
+		for _, i := range p.Elements() {
+			fmt.Printf("%s ", s.problem.Nodes[i])
 		}
 		fmt.Println()
 	}
@@ -0,0 +1,61 @@
+package successor
+
+import (
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/registry"
+)
+
+const name = "successor"
+
+func init() {
+	registry.RegisterFormulation(registry.Formulation{
+		Component: registry.Component{
+			Name:        name,
+			Description: "assigns each node's successor",
+		},
+		Orderings: []registry.Ordering{
+			{
+				Component: registry.Component{Name: "greedy", Description: "nodes with the cheapest outgoing arcs first"},
+				Create:    static((*State).orderingGreedy),
+			},
+			{
+				Component: registry.Component{Name: "input", Description: "nodes in input order"},
+				Create:    static((*State).orderingInput),
+			},
+			{
+				Component: registry.Component{Name: "regret", Description: "nodes with the largest difference between their two cheapest arcs first"},
+				Create:    static((*State).orderingRegret),
+			},
+			{
+				Component: registry.Component{Name: "fail-first", Description: "node with the smallest feasible domain at each state"},
+				Create:    dynamic((*State).selectFailFirst),
+			},
+			{
+				Component: registry.Component{Name: "dynamic-regret", Description: "node with the largest difference between its two cheapest arcs at each state"},
+				Create:    dynamic((*State).selectRegret),
+			},
+			{
+				Component:         registry.Component{Name: "ap-spread", Description: "node with the largest difference between its two smallest AP reduced costs at each state"},
+				RequiresInference: true,
+				Create:            dynamic((*State).selectAPSpread),
+			},
+		},
+		CreateRootState: func(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) ddo.State {
+			return CreateRootState(problem, infer, relax, ordering, width, verbosity)
+		},
+		CreateSolutionState: func(root ddo.State, solution *tsppd.Solution) ddo.State {
+			return CreateSolutionState(root.(*State), solution)
+		},
+	})
+}
+
+// static returns an ordering constructor that always creates o.
+func static(o StaticOrdering) func(*tsppd.Problem) interface{} {
+	return func(*tsppd.Problem) interface{} { return o }
+}
+
+// dynamic returns an ordering constructor that always creates o.
+func dynamic(o Selector) func(*tsppd.Problem) interface{} {
+	return func(*tsppd.Problem) interface{} { return o }
+}
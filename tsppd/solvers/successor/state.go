@@ -3,9 +3,10 @@ package successor
 import (
 	"fmt"
 
+	"github.com/ryanjoneil/tsppd-dd/bitset"
 	"github.com/ryanjoneil/tsppd-dd/ddo"
 	"github.com/ryanjoneil/tsppd-dd/tsppd"
-	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/apdual"
+	"github.com/ryanjoneil/tsppd-dd/tsppd/solvers/inference"
 )
 
 // State represents a current feasible path order.
@@ -13,37 +14,38 @@ type State struct {
 	cost int64
 
 	// These are indexed the same way problem.Nodes is.
-	domain  []int     // Unused values for next, i.e. {j | there is no next[i] = j}
-	partial []*[]bool // partial[i] = nodes contained in partial route of which i is a part
-	prev    []int     // prev[i] = j if (j i)
-	next    []int     // next[i] = j if (i j)
-	pred    []*[]bool // pred[i] = {nodes that must precede i}
-	succ    []*[]bool // succ[i] = {nodes that must succeed i}
-
-	// This is the order we assign to next in.
-	ordering []int
-	orderIdx int
+	domain  []int         // Unused values for next, i.e. {j | there is no next[i] = j}
+	partial []*bitset.Set // partial[i] = nodes contained in partial route of which i is a part
+	prev    []int         // prev[i] = j if (j i)
+	next    []int         // next[i] = j if (i j)
+	pred    []*bitset.Set // pred[i] = {nodes that must precede i}
+	succ    []*bitset.Set // succ[i] = {nodes that must succeed i}
+
+	// This is the order we assign to next in. Static orderings are fixed
+	// at the root. Dynamic orderings select from unassigned at each state.
+	ordering   []int
+	orderIdx   int
+	selector   Selector
+	unassigned *bitset.Set // Variables i with no next[i] assigned
+	last       int         // Variable assigned to get to this state
 
 	problem   *tsppd.Problem
 	verbosity uint
 	width     uint
-	ap        *apdual.State
+	dual      tsppd.InferenceDual
 }
 
 // CreateRootState makes the initial state for a successor DD TSPPD solver.
 func CreateRootState(problem *tsppd.Problem, infer, relax, ordering string, width, verbosity uint) *State {
-	var ap *apdual.State
-	if infer == "ap" {
-		ap = apdual.CreateAPDualState(problem)
-	}
-
 	s := &State{
 		cost: 0,
 
 		problem:   problem,
 		verbosity: verbosity,
 		width:     width,
-		ap:        ap,
+		dual:      inference.CreateInferenceDual(problem, infer),
+
+		last: -1,
 	}
 
 	s.initDomain()
@@ -72,43 +74,21 @@ func (s *State) IsSolved() bool {
 
 // Next creates the next feasible states accessible from a State.
 func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
-	if s.orderIdx >= len(s.ordering) {
+	index1 := s.variable(inferenceDual)
+	if index1 < 0 {
 		return []ddo.State{}
 	}
 
 	states := make([]ddo.State, 0, len(s.domain))
-	index1 := s.ordering[s.orderIdx]
+	unassigned := s.unassigned.Copy()
+	unassigned.Remove(index1)
 
 	for _, index2 := range s.feasible(index1) {
-		if inferenceDual != nil && inferenceDual.(*apdual.State).FilterIndex(index1, index2, incumbent) {
+		if inferenceDual != nil && inferenceDual.(tsppd.InferenceDual).FilterIndex(index1, index2, incumbent) {
 			continue
 		}
 
-		nextPartial := s.nextPartial(index1, index2)
-
-		state := &State{
-			cost: s.nextCost(index1, index2),
-
-			domain:  s.nextDomain(index2),
-			partial: nextPartial,
-			prev:    s.nextPrev(index1, index2),
-			next:    s.nextNext(index1, index2),
-			pred:    s.nextPred(index1, index2, nextPartial[index1]),
-			succ:    s.nextSucc(index1, index2, nextPartial[index1]),
-
-			ordering: s.ordering,
-			orderIdx: s.orderIdx + 1,
-
-			problem:   s.problem,
-			verbosity: s.verbosity,
-			width:     s.width,
-			ap:        s.ap,
-		}
-
-		state.inferPred(index1)
-		state.inferSucc(index1)
-
-		states = append(states, state)
+		states = append(states, s.assign(index1, index2, unassigned))
 
 		if s.verbosity == 2 {
 			fmt.Println()
@@ -120,11 +100,26 @@ func (s *State) Next(inferenceDual ddo.State, incumbent ddo.State) []ddo.State {
 	return states
 }
 
+// CreateSolutionState converts a complete solution into a State by
+// assigning each node's successor along its path.
+func CreateSolutionState(root *State, solution *tsppd.Solution) *State {
+	s := root
+	for i := 0; i < len(solution.Path)-1; i++ {
+		index1, _ := s.problem.Index(solution.Path[i])
+		index2, _ := s.problem.Index(solution.Path[i+1])
+
+		unassigned := s.unassigned.Copy()
+		unassigned.Remove(index1)
+		s = s.assign(index1, index2, unassigned)
+	}
+	return s
+}
+
 // Solution returns the full or partial solution of a sequential TSPPD State.
 func (s *State) Solution() *tsppd.Solution {
 	path := []string{}
 
-	current := 0
+	current := s.problem.StartIndex()
 	for current >= 0 {
 		path = append(path, s.problem.Nodes[current])
 		current = s.next[current]
@@ -136,50 +131,51 @@ func (s *State) Solution() *tsppd.Solution {
 	}
 }
 
+// assign creates a new State with next[index1] = index2.
+func (s *State) assign(index1, index2 int, unassigned *bitset.Set) *State {
+	nextPartial := s.nextPartial(index1, index2)
+
+	state := &State{
+		cost: s.nextCost(index1, index2),
+
+		domain:  s.nextDomain(index2),
+		partial: nextPartial,
+		prev:    s.nextPrev(index1, index2),
+		next:    s.nextNext(index1, index2),
+		pred:    s.nextPred(index1, index2, nextPartial[index1]),
+		succ:    s.nextSucc(index1, index2, nextPartial[index1]),
+
+		ordering:   s.ordering,
+		orderIdx:   s.orderIdx + 1,
+		selector:   s.selector,
+		unassigned: unassigned,
+		last:       index1,
+
+		problem:   s.problem,
+		verbosity: s.verbosity,
+		width:     s.width,
+		dual:      s.dual,
+	}
+
+	state.inferPred(index1)
+	state.inferSucc(index1)
+	return state
+}
+
 // Infer creates an inference diagram.
 func (s *State) Infer() *ddo.Diagram {
-	if s.ap == nil {
+	if s.dual == nil {
 		return nil
 	}
 
-	if s.orderIdx > 0 {
-		s.ap = s.ap.Copy()
-
-		index1 := s.ordering[s.orderIdx-1]
+	if s.last >= 0 {
+		// We can't connect anything but last to its next, and last's
+		// predecessors can't connect to its successors.
+		index1 := s.last
 		index2 := s.next[index1]
-
-		// We can't connect anything but index to index2.
-		for index3 := 0; index3 < len(s.problem.Nodes); index3++ {
-			if index3 != index1 {
-				s.ap.Remove(index3, index2)
-			}
-		}
-
-		// index1's predecessors can't connect to its successors.
-		preds := make([]int, 0, len(s.next))
-		succs := make([]int, 0, len(s.next))
-
-		for index3, v := range *s.pred[index1] {
-			if v {
-				preds = append(preds, index3)
-			}
-		}
-
-		for index3, v := range *s.succ[index1] {
-			if v {
-				succs = append(succs, index3)
-			}
-		}
-
-		for _, index3 := range preds {
-			for _, index4 := range succs {
-				s.ap.Remove(index3, index4)
-			}
-		}
-
-		s.ap.Solve()
+		s.dual = s.dual.Branch(index1, index2, s.pred[index1], s.succ[index1])
 	}
-	return ddo.CreateDiagram(s.ap, []ddo.Merger{}, s.width)
+	return ddo.CreateDiagram(s.dual, []ddo.Merger{}, s.width)
 }
 
 // Relax creates a relaxation diagram.
@@ -1,6 +1,28 @@
 package tsppd
 
+import (
+	"github.com/ryanjoneil/tsppd-dd/bitset"
+	"github.com/ryanjoneil/tsppd-dd/ddo"
+)
+
 // State types for TSPPD return a TSPPD Solution.
 type State interface {
 	Solution() *Solution
 }
+
+// InferenceDual types bound TSPPD states and filter arcs from their domains.
+type InferenceDual interface {
+	ddo.State
+
+	// Branch returns a new dual with the arc (index1 index2) forced on and
+	// the arcs from each node in from to each node in to forced off. The
+	// from and to sets may be nil.
+	Branch(index1, index2 int, from, to *bitset.Set) InferenceDual
+
+	// FilterIndex returns true if an arc can't be in a solution better
+	// than the incumbent.
+	FilterIndex(index1, index2 int, incumbent ddo.State) bool
+
+	// RC returns the reduced cost of an arc.
+	RC(index1, index2 int) int64
+}
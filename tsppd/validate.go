@@ -0,0 +1,153 @@
+package tsppd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxPathCost is the most any path through a valid Problem can cost. It
+// leaves room for solvers to add costs without overflowing.
+const MaxPathCost = math.MaxInt64 / 4
+
+// ValidationError collects every issue found when validating a Problem or
+// a Solution.
+type ValidationError []error
+
+// Error lists each issue on its own line.
+func (v ValidationError) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Validate checks that a Problem is well formed. It returns nil if the
+// Problem can be solved, or a ValidationError listing every issue found.
+func (p *Problem) Validate() error {
+	var errs ValidationError
+	addError := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	if len(p.Nodes) == 0 {
+		addError("problem has no nodes")
+	}
+
+	seen := map[string]bool{}
+	for _, node := range p.Nodes {
+		if seen[node] {
+			addError("node %s is duplicated", node)
+		}
+		seen[node] = true
+	}
+
+	if !seen[p.Start] {
+		addError("start node %s is missing", p.Start)
+	}
+	if !seen[p.End] {
+		addError("end node %s is missing", p.End)
+	}
+	if p.Start == p.End {
+		addError("start and end nodes are both %s", p.Start)
+	}
+
+	// Edges must be a square matrix matching the nodes.
+	if len(p.Edges) != len(p.Nodes) {
+		addError("edges have %d rows, expected %d", len(p.Edges), len(p.Nodes))
+	}
+	square := len(p.Edges) == len(p.Nodes)
+	for row, edges := range p.Edges {
+		if len(edges) != len(p.Nodes) {
+			addError("edges row %d has %d columns, expected %d", row, len(edges), len(p.Nodes))
+			square = false
+		}
+	}
+
+	// Costs must be nonnegative, and small enough that no path cost
+	// overflows.
+	if square {
+		var total int64
+		for row, edges := range p.Edges {
+			var max int64
+			for col, cost := range edges {
+				if row == col {
+					continue
+				}
+				if cost < 0 {
+					addError("edge %s -> %s has negative cost %d", p.Nodes[row], p.Nodes[col], cost)
+				} else if cost > max {
+					max = cost
+				}
+			}
+			if total += max; total > MaxPathCost || total < 0 {
+				addError("edge costs are too large, paths may cost more than %d", int64(MaxPathCost))
+				total = -1
+				break
+			}
+		}
+
+		// Relaxations price infeasible arcs at InfeasibleCost, and may use
+		// one into every node.
+		if total >= 0 && len(p.Nodes) > 0 && total+1 > MaxPathCost/int64(len(p.Nodes)) {
+			addError("edge costs are too large, relaxations may cost more than %d", int64(MaxPathCost))
+		}
+	}
+
+	// Precedence must pair each pickup with exactly one known delivery.
+	pickups := make([]string, 0, len(p.Precedence))
+	for pickup := range p.Precedence {
+		pickups = append(pickups, pickup)
+	}
+	sort.Strings(pickups)
+
+	pickupOf := map[string]string{}
+	for _, pickup := range pickups {
+		delivery := p.Precedence[pickup]
+		if !seen[pickup] {
+			addError("precedence names unknown pickup %s", pickup)
+		}
+		if !seen[delivery] {
+			addError("precedence names unknown delivery %s for pickup %s", delivery, pickup)
+		}
+		if pickup == delivery {
+			addError("pickup %s is its own delivery", pickup)
+		}
+
+		for _, node := range []string{pickup, delivery} {
+			if node == p.Start || node == p.End {
+				addError("depot node %s can't be a pickup or delivery", node)
+			}
+		}
+
+		if other, ok := pickupOf[delivery]; ok {
+			addError("delivery %s is paired with pickups %s and %s", delivery, other, pickup)
+		}
+		pickupOf[delivery] = pickup
+	}
+
+	for _, pickup := range pickups {
+		if other, ok := pickupOf[pickup]; ok {
+			addError("node %s is a pickup and the delivery for %s", pickup, other)
+		}
+	}
+
+	// Every other node must be part of a pickup and delivery pair.
+	for _, node := range p.Nodes {
+		if node == p.Start || node == p.End {
+			continue
+		}
+		_, isPickup := p.Precedence[node]
+		_, isDelivery := pickupOf[node]
+		if !isPickup && !isDelivery {
+			addError("node %s is not the start, the end, or in a pickup and delivery pair", node)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// verify implements the verify subcommand, which checks a solution against
+// a problem independently of any solver.
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	input := fs.String("input", "-", "input json file")
+	solutionFile := fs.String("solution", "", "solution file from -solution or -output json/jsonl, or a path of node names")
+	cost := fs.Int64("cost", -1, "expected cost (default: the cost in the solution file, if any)")
+	fs.Parse(args)
+
+	if *solutionFile == "" {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("solution file required"))
+		os.Exit(1)
+	}
+
+	problem := readProblem(*input)
+	path, claimed, err := readSolution(*solutionFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *solutionFile, err)
+		os.Exit(1)
+	}
+	if *cost >= 0 {
+		claimed = cost
+	}
+
+	solution := &tsppd.Solution{Problem: problem, Path: path}
+	if err := verifySolution(solution, claimed); err != nil {
+		for _, e := range err.(tsppd.ValidationError) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *solutionFile, e)
+		}
+		os.Exit(1)
+	}
+
+	computed, _ := solution.Cost()
+	fmt.Printf("valid: cost %d\n", computed)
+}
+
+// verifySolution validates a solution and checks that its cost matches
+// the claimed cost, if there is one.
+func verifySolution(solution *tsppd.Solution, claimed *int64) error {
+	var errs tsppd.ValidationError
+	if err := solution.Validate(); err != nil {
+		errs = append(errs, err.(tsppd.ValidationError)...)
+	}
+
+	if cost, ok := solution.Cost(); !ok {
+		errs = append(errs, fmt.Errorf("path cost can't be computed"))
+	} else if claimed != nil && cost != *claimed {
+		errs = append(errs, fmt.Errorf("path cost is %d, but %d was reported", cost, *claimed))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// readSolution reads a path and its claimed cost, if any, from a file. The
+// file can be written by -solution, by -output json or jsonl, in which case
+// the last record with a path is used, or contain node names separated by
+// spaces.
+func readSolution(filename string) ([]string, *int64, error) {
+	var b []byte
+	var err error
+	if filename == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseSolution(b)
+}
+
+// parseSolution reads a path and its claimed cost, if any, from the
+// contents of a solution file.
+func parseSolution(b []byte) ([]string, *int64, error) {
+	type record struct {
+		Path   []string `json:"path"`
+		Cost   *int64   `json:"cost"`
+		Primal *int64   `json:"primal"`
+	}
+
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || (b[0] != '{' && b[0] != '[') {
+		path, err := tsppd.ParsePath(string(b))
+		return path, nil, err
+	}
+
+	var records []record
+	var err error
+	if b[0] == '[' {
+		err = json.Unmarshal(b, &records)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		for decoder.More() {
+			var r record
+			if err = decoder.Decode(&r); err != nil {
+				break
+			}
+			records = append(records, r)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if len(r.Path) == 0 {
+			continue
+		}
+		if r.Cost != nil {
+			return r.Path, r.Cost, nil
+		}
+		return r.Path, r.Primal, nil
+	}
+	return nil, nil, fmt.Errorf("no solution found")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ryanjoneil/tsppd-dd/tsppd"
+)
+
+// FuzzParseSolution parses arbitrary solution files and checks any path
+// found against a small problem.
+func FuzzParseSolution(f *testing.F) {
+	problem, err := tsppd.Decode([]byte(`{
+		"Nodes": ["+0", "-0", "+1", "-1", "+2", "-2"],
+		"Precedence": {"+1": "-1", "+2": "-2"},
+		"Coordinates": {"+0": [0, 0], "-0": [0, 0], "+1": [3, 4], "-1": [6, 8], "+2": [1, 1], "-2": [5, 2]},
+		"Metric": "euclidean"
+	}`))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, solution := range []string{
+		"+0 +1 -1 +2 -2 -0",
+		"+0,+2,+1,-1,-2,-0",
+		`{"instance": "", "path": ["+0", "+1", "-1", "+2", "-2", "-0"], "cost": 24}`,
+		`[{"type": "solution", "path": ["+0", "+2", "-2", "+1", "-1", "-0"], "primal": 20}]`,
+		`{"path": ["+0", "+1"]}` + "\n" + `{"path": ["-1", "-0"], "primal": 3}`,
+		`{"path": [`,
+		"",
+	} {
+		f.Add([]byte(solution))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		path, claimed, err := parseSolution(b)
+		if err != nil {
+			return
+		}
+		if len(path) == 0 {
+			t.Fatalf("no error, but path is empty")
+		}
+		verifySolution(&tsppd.Solution{Problem: &problem, Path: path}, claimed)
+	})
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -15,12 +17,16 @@ type output struct {
 	flags   *flags
 	problem *tsppd.Problem
 	writer  *csv.Writer
+	records []*jsonRecord
 }
 
 func createOutput(f *flags, problem *tsppd.Problem) *output {
 	var writer *csv.Writer
 
-	if f.verbosity() == 1 {
+	if f.output() == "json" || f.output() == "jsonl" {
+		// JSON records are self-describing and need no header.
+
+	} else if f.verbosity() == 1 {
 		fmt.Print("instance        size   form        infer  relax  ")
 		fmt.Println("ordering  width     batch  workers  clock    cpu      primal    optimal  nodes     fails")
 		for i := 0; i < 150; i++ {
@@ -65,7 +71,10 @@ func createOutput(f *flags, problem *tsppd.Problem) *output {
 func (o *output) write(b *ddo.Bounds, stats ddo.Statistics) {
 	solution := b.Primal.(tsppd.State).Solution()
 
-	if o.flags.verbosity() == 1 {
+	if o.isJSON() {
+		o.writeJSON(o.createJSONRecord("solution", b.Primal, stats))
+
+	} else if o.flags.verbosity() == 1 {
 		fmt.Printf(
 			"%-16s%-7d%-12s%-7s%-7s%-10s%-10d%-7d%-9d%-9.3f%-9.3f%-10d%-9t%-10d%-10d\n",
 			solution.Problem.Name,
@@ -109,3 +118,67 @@ func (o *output) write(b *ddo.Bounds, stats ddo.Statistics) {
 		o.writer.Flush()
 	}
 }
+
+// finish writes a summary once search ends. Only JSON output has one.
+func (o *output) finish(incumbent ddo.State, stats ddo.Statistics) {
+	if o.isJSON() {
+		record := o.createJSONRecord("summary", incumbent, stats)
+		record.Termination = stats.Termination.String()
+		o.writeJSON(record)
+		o.flushJSON()
+	}
+}
+
+func (o *output) isJSON() bool {
+	return o.flags.output() == "json" || o.flags.output() == "jsonl"
+}
+
+// solutionFile is the format written by the -solution flag.
+type solutionFile struct {
+	Instance    string   `json:"instance"`
+	Path        []string `json:"path"`
+	Cost        *int64   `json:"cost"`
+	Optimal     bool     `json:"optimal"`
+	Bound       int64    `json:"bound"`
+	Termination string   `json:"termination"`
+}
+
+// writeSolution writes the best route found to a file. The file is written
+// in full and then renamed, so readers never see a partial solution.
+func writeSolution(filename string, problem *tsppd.Problem, incumbent ddo.State, stats ddo.Statistics) error {
+	file := solutionFile{
+		Instance:    problem.Name,
+		Path:        []string{},
+		Optimal:     stats.Optimal,
+		Bound:       stats.DualBound,
+		Termination: stats.Termination.String(),
+	}
+
+	var verifyErr error
+	if incumbent != nil {
+		solution := incumbent.(tsppd.State).Solution()
+		cost, ok := solution.Cost()
+		if !ok {
+			verifyErr = fmt.Errorf("solution: path contains an invalid arc")
+		} else if cost != incumbent.Cost() {
+			verifyErr = fmt.Errorf("solution: path cost %d does not match search cost %d", cost, incumbent.Cost())
+		} else {
+			file.Cost = &cost
+		}
+		file.Path = solution.Path
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp := filename + ".tmp"
+	if err := ioutil.WriteFile(temp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, filename); err != nil {
+		return err
+	}
+	return verifyErr
+}